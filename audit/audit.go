@@ -0,0 +1,133 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records every mutating ContractAPI/DiskAPI call into pluggable sinks.
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Record is an audit entry for a single mutating call.
+type Record struct {
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor,omitempty"`
+	Operation  string    `json:"operation"`
+	ContractID int64     `json:"contract_id,omitempty"`
+	DiskID     int64     `json:"disk_id,omitempty"`
+	SnapshotID int64     `json:"snapshot_id,omitempty"`
+	// Request is the redacted request payload
+	Request any    `json:"request,omitempty"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+	// Serial is the serial of the API error response, reported only on failures
+	Serial string `json:"serial,omitempty"`
+}
+
+// Sink writes audit records somewhere.
+type Sink interface {
+	Write(ctx context.Context, record *Record) error
+}
+
+// SinkFunc adapts a function to Sink.
+type SinkFunc func(ctx context.Context, record *Record) error
+
+func (f SinkFunc) Write(ctx context.Context, record *Record) error { return f(ctx, record) }
+
+type actorKey struct{}
+
+// WithActor returns a context carrying the actor to be recorded for calls made with it.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	return actor, ok
+}
+
+// Auditor builds records for mutating calls and writes them to its sinks.
+type Auditor struct {
+	Sinks []Sink
+	// DefaultActor is recorded when the context carries no actor
+	DefaultActor string
+	// Redactor masks sensitive values in request payloads. DefaultRedactor is used when nil.
+	Redactor Redactor
+	// OnSinkError is called when a sink fails to write a record. Sink errors never fail the audited call.
+	OnSinkError func(sink Sink, record *Record, err error)
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+}
+
+// NewAuditor returns an Auditor writing to the sinks.
+func NewAuditor(sinks ...Sink) *Auditor {
+	return &Auditor{Sinks: sinks}
+}
+
+func (a *Auditor) record(ctx context.Context, record Record, request any, err error) {
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	record.Time = now()
+
+	record.Actor = a.DefaultActor
+	if actor, ok := ActorFromContext(ctx); ok {
+		record.Actor = actor
+	}
+
+	if request != nil {
+		redactor := a.Redactor
+		if redactor == nil {
+			redactor = DefaultRedactor
+		}
+		record.Request = redactor(request)
+	}
+
+	record.Outcome = OutcomeSuccess
+	if err != nil {
+		record.Outcome = OutcomeFailure
+		record.Error = err.Error()
+		if res, ok := dedicatedstorage.ErrorResponse(err); ok {
+			record.Serial = res.Serial.Value
+		}
+	}
+
+	for _, sink := range a.Sinks {
+		if err := sink.Write(ctx, &record); err != nil && a.OnSinkError != nil {
+			a.OnSinkError(sink, &record, err)
+		}
+	}
+}
+
+// Close closes every sink implementing io.Closer.
+func (a *Auditor) Close() error {
+	var errs []error
+	for _, sink := range a.Sinks {
+		if c, ok := sink.(interface{ Close() error }); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,173 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (s *memorySink) Write(_ context.Context, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, *record)
+	return nil
+}
+
+func TestDiskOp(t *testing.T) {
+	assert := require.New(t)
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{Name: "contract"})
+	disk := store.AddDisk(v1.Disk{Name: "disk"})
+
+	sink := &memorySink{}
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	auditor := &Auditor{Sinks: []Sink{sink}, DefaultActor: "system", Now: func() time.Time { return now }}
+	op := NewDiskOp(fake.NewDiskOp(store), auditor)
+
+	ctx := WithActor(t.Context(), "alice")
+	created, err := op.CreateSnapshot(ctx, disk.ID, &v1.CreateSnapshotRequest{
+		DiskSnapshot: v1.CreateSnapshotRequestDiskSnapshot{
+			DedicatedStorageContract: v1.CreateSnapshotRequestDiskSnapshotDedicatedStorageContract{ID: contract.ID},
+			Name:                     "snapshot",
+			Description:              "secret",
+		},
+	})
+	assert.NoError(err)
+	assert.NoError(op.RestoreFromSnapshot(t.Context(), disk.ID, created.ID))
+	assert.Error(op.DeleteSnapshot(ctx, disk.ID, created.ID+1))
+	_, err = op.ListSnapshots(ctx, disk.ID)
+	assert.NoError(err)
+
+	assert.Len(sink.records, 3)
+
+	r := sink.records[0]
+	assert.Equal(now, r.Time)
+	assert.Equal("alice", r.Actor)
+	assert.Equal("Disk.CreateSnapshot", r.Operation)
+	assert.Equal(contract.ID, r.ContractID)
+	assert.Equal(disk.ID, r.DiskID)
+	assert.Equal(created.ID, r.SnapshotID)
+	assert.Equal(OutcomeSuccess, r.Outcome)
+	assert.Equal(map[string]any{
+		"DiskSnapshot": map[string]any{
+			"DedicatedStorageContract": map[string]any{"ID": float64(contract.ID)},
+			"Name":                     "snapshot",
+			"Description":              RedactedValue,
+		},
+	}, r.Request)
+
+	assert.Equal("system", sink.records[1].Actor)
+	assert.Equal("Disk.RestoreFromSnapshot", sink.records[1].Operation)
+
+	assert.Equal(OutcomeFailure, sink.records[2].Outcome)
+	assert.NotEmpty(sink.records[2].Error)
+}
+
+func TestContractOp(t *testing.T) {
+	assert := require.New(t)
+
+	store := fake.NewStore()
+	store.AddPlan(v1.DedicatedStorageContractPlan{ID: 1})
+
+	sink := &memorySink{}
+	op := NewContractOp(fake.NewContractOp(store), NewAuditor(sink))
+
+	created, err := op.Create(t.Context(), v1.CreateDedicatedStorageContractRequest{
+		DedicatedStorageContract: v1.CreateDedicatedStorageContractRequestDedicatedStorageContract{
+			Plan: v1.CreateDedicatedStorageContractRequestDedicatedStorageContractPlan{ID: 1},
+			Name: "contract",
+		},
+	})
+	assert.NoError(err)
+	assert.NoError(op.Delete(t.Context(), created.ID))
+
+	assert.Len(sink.records, 2)
+	assert.Equal(created.ID, sink.records[0].ContractID)
+	assert.Equal("Contract.Delete", sink.records[1].Operation)
+	assert.Nil(sink.records[1].Request)
+}
+
+func TestFileSink(t *testing.T) {
+	assert := require.New(t)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	sink, err := NewFileSink(path, &FileSinkOptions{MaxSize: 200, MaxBackups: 2})
+	assert.NoError(err)
+	for i := 0; i < 10; i++ {
+		assert.NoError(sink.Write(t.Context(), &Record{Operation: "Disk.DeleteSnapshot", SnapshotID: int64(i), Outcome: OutcomeSuccess}))
+	}
+	assert.NoError(sink.Close())
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		assert.NoError(err)
+		assert.LessOrEqual(info.Size(), int64(200))
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(os.IsNotExist(err))
+
+	f, err := os.Open(path) //nolint:gosec
+	assert.NoError(err)
+	defer f.Close() //nolint:errcheck
+
+	var last Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		assert.NoError(json.Unmarshal(scanner.Bytes(), &last))
+	}
+	assert.Equal(int64(9), last.SnapshotID)
+}
+
+func TestFileSink_RotationFailure(t *testing.T) {
+	assert := require.New(t)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// a non-empty directory cannot be replaced by the rotated file
+	assert.NoError(os.MkdirAll(filepath.Join(path+".1", "busy"), 0o700))
+
+	sink, err := NewFileSink(path, &FileSinkOptions{MaxSize: 200, MaxBackups: 1})
+	assert.NoError(err)
+	var rotateErrs int
+	for i := 0; i < 10; i++ {
+		if err := sink.Write(t.Context(), &Record{Operation: "Disk.DeleteSnapshot", SnapshotID: int64(i), Outcome: OutcomeSuccess}); err != nil {
+			assert.ErrorContains(err, "record written without rotating")
+			rotateErrs++
+		}
+	}
+	assert.NoError(sink.Close())
+	assert.NotZero(rotateErrs)
+
+	data, err := os.ReadFile(path) //nolint:gosec
+	assert.NoError(err)
+	assert.Len(bytes.Split(bytes.TrimSpace(data), []byte("\n")), 10)
+}
@@ -0,0 +1,153 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+const (
+	DefaultFileMaxSize    = 100 * 1024 * 1024
+	DefaultFileMaxBackups = 5
+)
+
+// FileSinkOptions configures the rotation of FileSink.
+type FileSinkOptions struct {
+	// MaxSize is the size in bytes at which the file is rotated. DefaultFileMaxSize is used when zero.
+	MaxSize int64
+	// MaxBackups is the number of rotated files kept as path.1, path.2, ... DefaultFileMaxBackups is used when zero.
+	MaxBackups int
+}
+
+var _ Sink = (*FileSink)(nil)
+
+// FileSink writes records to a file in JSON Lines format, rotating it by size.
+type FileSink struct {
+	path string
+	opts FileSinkOptions
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileSink opens or creates the file at path for appending.
+func NewFileSink(path string, opts *FileSinkOptions) (*FileSink, error) {
+	s := &FileSink{path: path}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.MaxSize <= 0 {
+		s.opts.MaxSize = DefaultFileMaxSize
+	}
+	if s.opts.MaxBackups <= 0 {
+		s.opts.MaxBackups = DefaultFileMaxBackups
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) Write(_ context.Context, record *Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return errors.New("audit: file sink is closed")
+	}
+	// a failed rotation must not lose the record, it is written to the current file
+	var rotateErr error
+	if s.size > 0 && s.size+int64(len(line)) > s.opts.MaxSize {
+		rotateErr = s.rotate()
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if rotateErr != nil {
+		return fmt.Errorf("audit: record written without rotating %s: %w", s.path, rotateErr)
+	}
+	return nil
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) open() error {
+	f, size, err := openAppend(s.path)
+	if err != nil {
+		return err
+	}
+	s.file, s.size = f, size
+	return nil
+}
+
+func openAppend(path string) (*os.File, int64, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close() //nolint:errcheck,gosec
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+// rotate renames the files before replacing the open one, so that the sink keeps writing to
+// the current file when any step fails
+func (s *FileSink) rotate() error {
+	for i := s.opts.MaxBackups - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", s.path, i)
+		to := fmt.Sprintf("%s.%d", s.path, i+1)
+		if err := os.Rename(from, to); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(s.path, s.path+".1"); err != nil {
+		return err
+	}
+	f, size, err := openAppend(s.path)
+	if err != nil {
+		// the open file is now path.1, it is rotated again on the next write
+		return err
+	}
+	s.file.Close() //nolint:errcheck,gosec
+	s.file, s.size = f, size
+	return nil
+}
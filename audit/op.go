@@ -0,0 +1,105 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+)
+
+var _ dedicatedstorage.ContractAPI = (*contractOp)(nil)
+
+type contractOp struct {
+	dedicatedstorage.ContractAPI
+	auditor *Auditor
+}
+
+// NewContractOp returns a ContractAPI which records every mutating call of api with auditor.
+func NewContractOp(api dedicatedstorage.ContractAPI, auditor *Auditor) dedicatedstorage.ContractAPI {
+	return &contractOp{ContractAPI: api, auditor: auditor}
+}
+
+func (op *contractOp) Create(ctx context.Context, request v1.CreateDedicatedStorageContractRequest) (*v1.DedicatedStorageContract, error) {
+	res, err := op.ContractAPI.Create(ctx, request)
+	record := Record{Operation: "Contract.Create"}
+	if res != nil {
+		record.ContractID = res.ID
+	}
+	op.auditor.record(ctx, record, request, err)
+	return res, err
+}
+
+func (op *contractOp) Update(ctx context.Context, id int64, request v1.UpdateDedicatedStorageContractRequest) (*v1.DedicatedStorageContract, error) {
+	res, err := op.ContractAPI.Update(ctx, id, request)
+	op.auditor.record(ctx, Record{Operation: "Contract.Update", ContractID: id}, request, err)
+	return res, err
+}
+
+func (op *contractOp) Delete(ctx context.Context, id int64) error {
+	err := op.ContractAPI.Delete(ctx, id)
+	op.auditor.record(ctx, Record{Operation: "Contract.Delete", ContractID: id}, nil, err)
+	return err
+}
+
+var _ dedicatedstorage.DiskAPI = (*diskOp)(nil)
+
+type diskOp struct {
+	dedicatedstorage.DiskAPI
+	auditor *Auditor
+}
+
+// NewDiskOp returns a DiskAPI which records every mutating call of api with auditor.
+func NewDiskOp(api dedicatedstorage.DiskAPI, auditor *Auditor) dedicatedstorage.DiskAPI {
+	return &diskOp{DiskAPI: api, auditor: auditor}
+}
+
+func (op *diskOp) CreateSnapshot(ctx context.Context, diskID int64, request *v1.CreateSnapshotRequest) (*v1.DiskSnapshot, error) {
+	res, err := op.DiskAPI.CreateSnapshot(ctx, diskID, request)
+	record := Record{Operation: "Disk.CreateSnapshot", DiskID: diskID}
+	if request != nil {
+		record.ContractID = request.DiskSnapshot.DedicatedStorageContract.ID
+	}
+	if res != nil {
+		record.SnapshotID = res.ID
+	}
+	op.auditor.record(ctx, record, request, err)
+	return res, err
+}
+
+func (op *diskOp) UpdateSnapshot(ctx context.Context, diskID, snapshotID int64, request *v1.UpdateSnapshotRequest) (*v1.DiskSnapshot, error) {
+	res, err := op.DiskAPI.UpdateSnapshot(ctx, diskID, snapshotID, request)
+	op.auditor.record(ctx, Record{Operation: "Disk.UpdateSnapshot", DiskID: diskID, SnapshotID: snapshotID}, request, err)
+	return res, err
+}
+
+func (op *diskOp) DeleteSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	err := op.DiskAPI.DeleteSnapshot(ctx, diskID, snapshotID)
+	op.auditor.record(ctx, Record{Operation: "Disk.DeleteSnapshot", DiskID: diskID, SnapshotID: snapshotID}, nil, err)
+	return err
+}
+
+func (op *diskOp) RestoreFromSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	err := op.DiskAPI.RestoreFromSnapshot(ctx, diskID, snapshotID)
+	op.auditor.record(ctx, Record{Operation: "Disk.RestoreFromSnapshot", DiskID: diskID, SnapshotID: snapshotID}, nil, err)
+	return err
+}
+
+func (op *diskOp) Expand(ctx context.Context, diskID int64, request *v1.ExpandDiskRequest) error {
+	err := op.DiskAPI.Expand(ctx, diskID, request)
+	op.auditor.record(ctx, Record{Operation: "Disk.Expand", DiskID: diskID}, request, err)
+	return err
+}
@@ -0,0 +1,74 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// RedactedValue replaces the values of redacted fields.
+const RedactedValue = "[REDACTED]"
+
+// Redactor returns a copy of the request payload which is safe to be recorded.
+type Redactor func(request any) any
+
+// DefaultRedactedFields are the fields masked by DefaultRedactor.
+// Descriptions are free text and may carry credentials or personal data.
+var DefaultRedactedFields = []string{"Description"}
+
+// DefaultRedactor masks DefaultRedactedFields.
+var DefaultRedactor = RedactFields(DefaultRedactedFields...)
+
+// RedactFields returns a Redactor which converts the payload into its JSON form and
+// masks the values of the named fields at any depth. Field names are matched case-insensitively.
+func RedactFields(fields ...string) Redactor {
+	names := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		names[strings.ToLower(f)] = true
+	}
+	return func(request any) any {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return nil
+		}
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil
+		}
+		return redact(v, names)
+	}
+}
+
+func redact(v any, names map[string]bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if names[strings.ToLower(k)] {
+				v[k] = RedactedValue
+				continue
+			}
+			v[k] = redact(child, names)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = redact(child, names)
+		}
+		return v
+	default:
+		return v
+	}
+}
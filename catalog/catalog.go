@@ -0,0 +1,227 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package catalog exports the configuration of contracts to a versioned JSON or YAML document
+// and imports it back, recreating or re-syncing the metadata of the contracts.
+//
+// The document also records the plans and the snapshot catalog of every contract for reference.
+// Snapshots themselves cannot be recreated and are never imported.
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	"github.com/sacloud/dedicated-storage-api-go/model"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+	"gopkg.in/yaml.v3"
+)
+
+// DocumentVersion is the version of the document format.
+const DocumentVersion = 1
+
+// Format is a serialization format of documents.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// FormatOf returns the format of a file from its extension, FormatJSON unless it is .yaml or .yml.
+func FormatOf(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	}
+	return FormatJSON
+}
+
+// Document is the exported configuration of contracts.
+type Document struct {
+	Version int `json:"version" yaml:"version"`
+	// SchemaVersion is the model.SchemaVersion of the exporter
+	SchemaVersion int        `json:"schema_version" yaml:"schema_version"`
+	ExportedAt    time.Time  `json:"exported_at" yaml:"exported_at"`
+	Plans         []Plan     `json:"plans" yaml:"plans"`
+	Contracts     []Contract `json:"contracts" yaml:"contracts"`
+}
+
+// Plan is a plan available when the document was exported.
+type Plan struct {
+	ID           int64              `json:"id" yaml:"id"`
+	Name         string             `json:"name" yaml:"name"`
+	ServiceClass types.ServiceClass `json:"service_class" yaml:"service_class"`
+}
+
+// Contract is the metadata of a contract. Contracts are matched by name on import.
+type Contract struct {
+	// ID is the ID of the contract when exported, for reference only
+	ID          int64    `json:"id,omitempty" yaml:"id,omitempty"`
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description" yaml:"description"`
+	Tags        []string `json:"tags" yaml:"tags"`
+	IconID      int64    `json:"icon_id,omitempty" yaml:"icon_id,omitempty"`
+	PlanID      int64    `json:"plan_id" yaml:"plan_id"`
+	// Snapshots are the snapshots of the contract when exported, for reference only
+	Snapshots []Snapshot `json:"snapshots,omitempty" yaml:"snapshots,omitempty"`
+}
+
+// Snapshot is an entry of the snapshot catalog of a contract.
+type Snapshot struct {
+	ID          int64               `json:"id" yaml:"id"`
+	Name        string              `json:"name" yaml:"name"`
+	Description string              `json:"description,omitempty" yaml:"description,omitempty"`
+	CreatedAt   time.Time           `json:"created_at" yaml:"created_at"`
+	State       types.SnapshotState `json:"state" yaml:"state"`
+	DiskID      int64               `json:"disk_id" yaml:"disk_id"`
+	DiskName    string              `json:"disk_name" yaml:"disk_name"`
+	DiskSizeMB  int64               `json:"disk_size_mb" yaml:"disk_size_mb"`
+}
+
+// ExportOptions configures Export.
+type ExportOptions struct {
+	// WithoutSnapshots leaves the snapshot catalog out of the document
+	WithoutSnapshots bool
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+}
+
+// Export reads the plans and all contracts with their snapshots into a document.
+func Export(ctx context.Context, api dedicatedstorage.ContractAPI, opts *ExportOptions) (*Document, error) {
+	const methodName = "Catalog.Export"
+
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+
+	op := model.NewContractOp(api)
+	plans, err := op.ListPlans(ctx)
+	if err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+	contracts, err := op.List(ctx)
+	if err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+
+	doc := &Document{
+		Version:       DocumentVersion,
+		SchemaVersion: model.SchemaVersion,
+		ExportedAt:    now(),
+		Plans:         make([]Plan, 0, len(plans)),
+		Contracts:     make([]Contract, 0, len(contracts)),
+	}
+	for _, plan := range plans {
+		doc.Plans = append(doc.Plans, Plan{ID: plan.ID, Name: plan.Name, ServiceClass: plan.ServiceClass})
+	}
+	for _, contract := range contracts {
+		entry := Contract{
+			ID:          contract.ID,
+			Name:        contract.Name,
+			Description: contract.Description,
+			Tags:        contract.Tags,
+			IconID:      contract.IconID,
+			PlanID:      contract.Plan.ID,
+		}
+		if !opts.WithoutSnapshots {
+			snapshots, err := op.ListSnapshots(ctx, contract.ID)
+			if err != nil {
+				return nil, dedicatedstorage.NewError(methodName, err)
+			}
+			for _, snapshot := range snapshots {
+				entry.Snapshots = append(entry.Snapshots, Snapshot{
+					ID:          snapshot.ID,
+					Name:        snapshot.Name,
+					Description: snapshot.Description,
+					CreatedAt:   snapshot.CreatedAt,
+					State:       snapshot.State,
+					DiskID:      snapshot.Disk.ID,
+					DiskName:    snapshot.Disk.Name,
+					DiskSizeMB:  snapshot.Disk.SizeMB,
+				})
+			}
+		}
+		doc.Contracts = append(doc.Contracts, entry)
+	}
+	return doc, nil
+}
+
+// Write writes the document in the format.
+func (d *Document) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSON, "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("unsupported format: %q", format)
+}
+
+// Read reads a document in the format and checks that its versions are supported.
+func Read(r io.Reader, format Format) (*Document, error) {
+	var doc Document
+	switch format {
+	case FormatJSON, "":
+		if err := json.NewDecoder(r).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("invalid document: %w", err)
+		}
+	case FormatYAML:
+		if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("invalid document: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format: %q", format)
+	}
+
+	if doc.Version != DocumentVersion {
+		return nil, fmt.Errorf("unsupported document version %d", doc.Version)
+	}
+	if doc.SchemaVersion > model.SchemaVersion {
+		return nil, fmt.Errorf("document schema version %d is newer than %d", doc.SchemaVersion, model.SchemaVersion)
+	}
+	return &doc, nil
+}
+
+// Load reads a document from a file in the format of its extension.
+func Load(path string) (*Document, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+	doc, err := Read(f, FormatOf(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func newStore() *fake.Store {
+	store := fake.NewStore()
+	store.AddPlan(v1.DedicatedStorageContractPlan{ID: 1, Name: "standard", ServiceClass: "cloud/dedicated-storage/standard"})
+	store.AddPlan(v1.DedicatedStorageContractPlan{ID: 2, Name: "large", ServiceClass: "cloud/dedicated-storage/large"})
+	return store
+}
+
+func TestExport(t *testing.T) {
+	assert := require.New(t)
+
+	store := newStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{
+		Name: "db", Description: "database", Tags: []string{"env=prod"}, Plan: v1.Plan{ID: 1, Name: "standard"},
+	})
+	disk := store.AddDisk(v1.Disk{Name: "db-disk", SizeMB: 20480})
+	store.AddSnapshot(v1.DiskSnapshot{
+		Name:                     "nightly",
+		SnapshotState:            "available",
+		Disk:                     disk,
+		DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+	})
+
+	exportedAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	doc, err := Export(t.Context(), fake.NewContractOp(store), &ExportOptions{Now: func() time.Time { return exportedAt }})
+	assert.NoError(err)
+	assert.Equal(DocumentVersion, doc.Version)
+	assert.Len(doc.Plans, 2)
+	assert.Len(doc.Contracts, 1)
+	assert.Equal("db", doc.Contracts[0].Name)
+	assert.EqualValues(1, doc.Contracts[0].PlanID)
+	assert.Len(doc.Contracts[0].Snapshots, 1)
+	assert.Equal("db-disk", doc.Contracts[0].Snapshots[0].DiskName)
+
+	for _, format := range []Format{FormatJSON, FormatYAML} {
+		var buf bytes.Buffer
+		assert.NoError(doc.Write(&buf, format))
+		read, err := Read(&buf, format)
+		assert.NoError(err, format)
+		assert.Equal(doc.Contracts, read.Contracts, format)
+		assert.True(exportedAt.Equal(read.ExportedAt), format)
+	}
+
+	path := filepath.Join(t.TempDir(), "catalog.yml")
+	assert.NoError(os.WriteFile(path, []byte("version: 2\n"), 0o600))
+	_, err = Load(path)
+	assert.ErrorContains(err, "unsupported document version 2")
+}
+
+func TestImporter(t *testing.T) {
+	assert := require.New(t)
+
+	store := newStore()
+	db := store.AddContract(v1.DedicatedStorageContract{Name: "db", Description: "old", Plan: v1.Plan{ID: 1}})
+	store.AddContract(v1.DedicatedStorageContract{Name: "logs", Tags: []string{"b", "a"}, Plan: v1.Plan{ID: 1}})
+	store.AddContract(v1.DedicatedStorageContract{Name: "archive", Plan: v1.Plan{ID: 1}})
+
+	doc := &Document{
+		Version: DocumentVersion,
+		Contracts: []Contract{
+			{Name: "db", Description: "database", Tags: []string{"env=prod"}, PlanID: 1},
+			{Name: "logs", Tags: []string{"a", "b"}, PlanID: 1},
+			{Name: "archive", PlanID: 2},
+			{Name: "cache", PlanID: 2},
+			{Name: "unknown", PlanID: 9},
+		},
+	}
+	importer := NewImporter(fake.NewContractOp(store))
+
+	changes, err := importer.Preview(t.Context(), doc)
+	assert.NoError(err)
+	assert.Equal([]Action{ActionUpdate, ActionUnchanged, ActionConflict, ActionCreate, ActionConflict},
+		[]Action{changes[0].Action, changes[1].Action, changes[2].Action, changes[3].Action, changes[4].Action})
+	assert.Equal(db.ID, changes[0].ContractID)
+	assert.Equal([]FieldChange{
+		{Field: "description", Old: "old", New: "database"},
+		{Field: "tags", Old: "", New: "env=prod"},
+	}, changes[0].Fields)
+	assert.Contains(changes[4].Reason, "plan 9 is not available")
+
+	var buf bytes.Buffer
+	assert.NoError(WriteChanges(&buf, changes))
+	assert.Contains(buf.String(), `    description: "old" -> "database"`)
+	assert.True(strings.HasPrefix(buf.String(), "~ db ("))
+
+	changes, err = importer.Apply(t.Context(), doc)
+	assert.True(errors.Is(err, ErrConflict))
+	assert.NotZero(changes[3].ContractID)
+
+	contracts, err := fake.NewContractOp(store).List(t.Context())
+	assert.NoError(err)
+	assert.Len(contracts.DedicatedStorageContracts, 4)
+
+	changes, err = importer.Preview(t.Context(), doc)
+	assert.NoError(err)
+	assert.Equal(ActionUnchanged, changes[0].Action)
+	assert.Equal(ActionUnchanged, changes[3].Action)
+
+	t.Run("remove icon", func(t *testing.T) {
+		assert := require.New(t)
+		withIcon := store.AddContract(v1.DedicatedStorageContract{Name: "icon", Plan: v1.Plan{ID: 1}, Icon: v1.NewOptNilIcon(v1.Icon{ID: 5})})
+		doc := &Document{Version: DocumentVersion, Contracts: []Contract{{Name: "icon", PlanID: 1}}}
+
+		changes, err := importer.Apply(t.Context(), doc)
+		assert.NoError(err)
+		assert.Equal(ActionUpdate, changes[0].Action)
+		assert.Equal([]FieldChange{{Field: "icon_id", Old: "5", New: ""}}, changes[0].Fields)
+
+		changes, err = importer.Preview(t.Context(), doc)
+		assert.NoError(err)
+		assert.Equal(ActionUnchanged, changes[0].Action)
+		assert.Equal(withIcon.ID, changes[0].ContractID)
+	})
+}
+
+func TestImporter_ApplyChanges(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+
+	store := newStore()
+	db := store.AddContract(v1.DedicatedStorageContract{Name: "db", Description: "old", Plan: v1.Plan{ID: 1}})
+	logs := store.AddContract(v1.DedicatedStorageContract{Name: "logs", Description: "old", Plan: v1.Plan{ID: 1}})
+	doc := &Document{
+		Version: DocumentVersion,
+		Contracts: []Contract{
+			{Name: "db", Description: "database", PlanID: 1},
+			{Name: "logs", Description: "logs", PlanID: 1},
+			{Name: "cache", PlanID: 2},
+			{Name: "queue", PlanID: 2},
+		},
+	}
+	importer := NewImporter(fake.NewContractOp(store))
+	changes, err := importer.Preview(ctx, doc)
+	assert.NoError(err)
+	assert.Equal([]Action{ActionUpdate, ActionUpdate, ActionCreate, ActionCreate},
+		[]Action{changes[0].Action, changes[1].Action, changes[2].Action, changes[3].Action})
+
+	_, err = importer.ApplyChanges(ctx, doc, changes[:2])
+	assert.ErrorContains(err, "not a preview of the document")
+
+	// logs is edited and a cache created by someone else after the preview
+	contracts := fake.NewContractOp(store)
+	_, err = contracts.Update(ctx, logs.ID, (&dedicatedstorage.ContractUpdateParams{Name: "logs", Description: "edited"}).Request())
+	assert.NoError(err)
+	_, err = contracts.Create(ctx, (&dedicatedstorage.ContractCreateParams{PlanID: 2, Name: "cache"}).Request())
+	assert.NoError(err)
+
+	applied, err := importer.ApplyChanges(ctx, doc, changes)
+	assert.True(errors.Is(err, ErrConflict))
+	assert.Equal([]Action{ActionUpdate, ActionConflict, ActionConflict, ActionCreate},
+		[]Action{applied[0].Action, applied[1].Action, applied[2].Action, applied[3].Action})
+	assert.Contains(applied[1].Reason, "changed since the preview")
+	assert.NotZero(applied[3].ContractID)
+	assert.Equal(ActionUpdate, changes[1].Action, "the approved changes are not modified")
+
+	current, err := contracts.Read(ctx, logs.ID)
+	assert.NoError(err)
+	assert.Equal("edited", current.Description, "the edit is not overwritten")
+	current, err = contracts.Read(ctx, db.ID)
+	assert.NoError(err)
+	assert.Equal("database", current.Description)
+}
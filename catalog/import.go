@@ -0,0 +1,301 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/model"
+	"github.com/sacloud/saclient-go"
+)
+
+// ErrConflict is returned by Importer.Apply and Importer.ApplyChanges when some contracts of the document cannot be imported.
+var ErrConflict = errors.New("conflicting contract")
+
+// Action is what importing a contract of a document does.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionUnchanged Action = "unchanged"
+	// ActionConflict is a contract which cannot be imported, see Change.Reason
+	ActionConflict Action = "conflict"
+)
+
+// FieldChange is a field of a contract changed by an import.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Change is the effect of importing a contract of a document.
+type Change struct {
+	Action Action `json:"action"`
+	Name   string `json:"name"`
+	// ContractID is the ID of the existing contract, or of the created one after Importer.Apply and Importer.ApplyChanges
+	ContractID int64         `json:"contract_id,omitempty"`
+	Fields     []FieldChange `json:"fields,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+}
+
+// Importer recreates or re-syncs the metadata of contracts from a document.
+//
+// Contracts are matched by name. Unmatched contracts are created, matched ones get the name,
+// description, tags and icon of the document. The plan of an existing contract cannot be changed,
+// a contract of another plan is a conflict, as is a plan unavailable in the target.
+// Contracts which are not in the document are left untouched.
+type Importer struct {
+	Contracts dedicatedstorage.ContractAPI
+}
+
+// NewImporter returns an Importer to the contracts of api.
+func NewImporter(api dedicatedstorage.ContractAPI) *Importer {
+	return &Importer{Contracts: api}
+}
+
+// Preview returns the changes importing the document would make, in the order of its contracts,
+// without making them.
+func (i *Importer) Preview(ctx context.Context, doc *Document) ([]Change, error) {
+	const methodName = "Catalog.Preview"
+
+	changes, err := i.preview(ctx, doc)
+	if err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+	return changes, nil
+}
+
+// Apply imports the document and returns the changes made. Conflicting contracts are skipped and
+// reported by an error wrapping ErrConflict, after the others were imported.
+// It is Preview followed by ApplyChanges.
+func (i *Importer) Apply(ctx context.Context, doc *Document) ([]Change, error) {
+	const methodName = "Catalog.Apply"
+
+	changes, err := i.preview(ctx, doc)
+	if err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+	return i.ApplyChanges(ctx, doc, changes)
+}
+
+// ApplyChanges makes the changes returned by Preview for the document, once they were approved,
+// and returns the changes made. Contracts are updated by the IDs matched by Preview, without matching
+// them again. Conflicting contracts are skipped and reported by an error wrapping ErrConflict,
+// after the others were imported.
+//
+// Contracts which changed since the preview become conflicts instead of being overwritten: an updated
+// contract must still differ from the document by the previewed fields, and a created contract must
+// still have no namesake.
+func (i *Importer) ApplyChanges(ctx context.Context, doc *Document, changes []Change) ([]Change, error) {
+	const methodName = "Catalog.ApplyChanges"
+
+	if len(changes) != len(doc.Contracts) {
+		return nil, dedicatedstorage.NewError(methodName, fmt.Errorf("%d changes for %d contracts, the changes are not a preview of the document", len(changes), len(doc.Contracts)))
+	}
+	for n := range changes {
+		if changes[n].Name != doc.Contracts[n].Name {
+			return nil, dedicatedstorage.NewError(methodName, fmt.Errorf("change %q for contract %q, the changes are not a preview of the document", changes[n].Name, doc.Contracts[n].Name))
+		}
+	}
+
+	op := model.NewContractOp(i.Contracts)
+	var existing []model.Contract
+	if slices.ContainsFunc(changes, func(c Change) bool { return c.Action == ActionCreate }) {
+		var err error
+		if existing, err = op.List(ctx); err != nil {
+			return nil, dedicatedstorage.NewError(methodName, err)
+		}
+	}
+
+	changes = slices.Clone(changes)
+	var conflicts []error
+	for n := range changes {
+		change := &changes[n]
+		entry := &doc.Contracts[n]
+		var err error
+		switch change.Action {
+		case ActionCreate:
+			if slices.ContainsFunc(existing, func(c model.Contract) bool { return c.Name == entry.Name }) {
+				change.Action = ActionConflict
+				change.Reason = fmt.Sprintf("a contract named %q was created since the preview", entry.Name)
+				break
+			}
+			var contract *v1.DedicatedStorageContract
+			contract, err = i.Contracts.Create(ctx, (&dedicatedstorage.ContractCreateParams{
+				PlanID:      entry.PlanID,
+				Name:        entry.Name,
+				Description: entry.Description,
+				Tags:        entry.Tags,
+				IconID:      entry.IconID,
+			}).Request())
+			if err == nil {
+				change.ContractID = contract.ID
+			}
+		case ActionUpdate:
+			var current *model.Contract
+			current, err = op.Read(ctx, change.ContractID)
+			if saclient.IsNotFoundError(err) {
+				change.Action = ActionConflict
+				change.Reason = fmt.Sprintf("contract %d was deleted since the preview", change.ContractID)
+				err = nil
+				break
+			}
+			if err != nil {
+				break
+			}
+			if !slices.Equal(diffContract(current, entry), change.Fields) {
+				change.Action = ActionConflict
+				change.Reason = fmt.Sprintf("contract %d changed since the preview", change.ContractID)
+				break
+			}
+			// the previewed contract is updated as is, a zero icon removing its icon
+			_, err = i.Contracts.Update(ctx, change.ContractID, (&dedicatedstorage.ContractUpdateParams{
+				Name:        entry.Name,
+				Description: entry.Description,
+				Tags:        entry.Tags,
+				IconID:      entry.IconID,
+			}).Request())
+		}
+		if err != nil {
+			return changes[:n], dedicatedstorage.NewError(methodName, fmt.Errorf("importing contract %q: %w", entry.Name, err))
+		}
+		if change.Action == ActionConflict {
+			conflicts = append(conflicts, fmt.Errorf("%w %q: %s", ErrConflict, change.Name, change.Reason))
+		}
+	}
+	if len(conflicts) > 0 {
+		return changes, dedicatedstorage.NewError(methodName, errors.Join(conflicts...))
+	}
+	return changes, nil
+}
+
+func (i *Importer) preview(ctx context.Context, doc *Document) ([]Change, error) {
+	seen := map[string]bool{}
+	for _, entry := range doc.Contracts {
+		if entry.Name == "" {
+			return nil, errors.New("document has a contract without name")
+		}
+		if seen[entry.Name] {
+			return nil, fmt.Errorf("document has several contracts named %q", entry.Name)
+		}
+		seen[entry.Name] = true
+	}
+
+	op := model.NewContractOp(i.Contracts)
+	plans, err := op.ListPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := op.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, 0, len(doc.Contracts))
+	for _, entry := range doc.Contracts {
+		change := Change{Name: entry.Name}
+		var matched []*model.Contract
+		for n := range contracts {
+			if contracts[n].Name == entry.Name {
+				matched = append(matched, &contracts[n])
+			}
+		}
+
+		switch {
+		case len(matched) > 1:
+			change.Action = ActionConflict
+			change.Reason = fmt.Sprintf("%d contracts are named %q", len(matched), entry.Name)
+		case len(matched) == 1 && matched[0].Plan.ID != entry.PlanID:
+			change.Action = ActionConflict
+			change.ContractID = matched[0].ID
+			change.Reason = fmt.Sprintf("contract %d has plan %d, the document requires plan %d", matched[0].ID, matched[0].Plan.ID, entry.PlanID)
+		case len(matched) == 1:
+			change.ContractID = matched[0].ID
+			change.Fields = diffContract(matched[0], &entry)
+			change.Action = ActionUnchanged
+			if len(change.Fields) > 0 {
+				change.Action = ActionUpdate
+			}
+		case !slices.ContainsFunc(plans, func(p model.Plan) bool { return p.ID == entry.PlanID }):
+			change.Action = ActionConflict
+			change.Reason = fmt.Sprintf("plan %d is not available", entry.PlanID)
+		default:
+			change.Action = ActionCreate
+			change.Fields = diffContract(&model.Contract{}, &entry)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// diffContract returns the fields of current differing from the entry
+func diffContract(current *model.Contract, entry *Contract) []FieldChange {
+	var fields []FieldChange
+	add := func(field, before, after string) {
+		if before != after {
+			fields = append(fields, FieldChange{Field: field, Old: before, New: after})
+		}
+	}
+	add("name", current.Name, entry.Name)
+	add("description", current.Description, entry.Description)
+	oldTags, newTags := slices.Clone(current.Tags), slices.Clone(entry.Tags)
+	slices.Sort(oldTags)
+	slices.Sort(newTags)
+	add("tags", strings.Join(oldTags, ","), strings.Join(newTags, ","))
+	add("icon_id", formatID(current.IconID), formatID(entry.IconID))
+	add("plan_id", formatID(current.Plan.ID), formatID(entry.PlanID))
+	return fields
+}
+
+func formatID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// WriteChanges writes the changes as a human readable diff, one contract per line followed by
+// its changed fields: "+" is created, "~" updated, "=" unchanged and "!" a conflict.
+func WriteChanges(w io.Writer, changes []Change) error {
+	marks := map[Action]string{ActionCreate: "+", ActionUpdate: "~", ActionUnchanged: "=", ActionConflict: "!"}
+	for _, change := range changes {
+		line := fmt.Sprintf("%s %s", marks[change.Action], change.Name)
+		if change.ContractID != 0 {
+			line += fmt.Sprintf(" (%d)", change.ContractID)
+		}
+		if change.Reason != "" {
+			line += ": " + change.Reason
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		for _, field := range change.Fields {
+			if _, err := fmt.Fprintf(w, "    %s: %q -> %q\n", field.Field, field.Old, field.New); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
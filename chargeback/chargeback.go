@@ -0,0 +1,284 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chargeback allocates the costs of dedicated storage contracts to their owners.
+//
+// The owner of a contract is read from its tags with a configurable key, such as "team=payments".
+// Costs are computed from the plan of the contract and the usage of its data and snapshot pools
+// with a price table supplied by the user. The API only reports the current usage, so reports are
+// based on a point-in-time reading of the pools, not on the usage over the month.
+package chargeback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	"github.com/sacloud/dedicated-storage-api-go/model"
+)
+
+// DefaultTagKey is the tag key naming the owner when Allocator.TagKey is empty.
+const DefaultTagKey = "owner"
+
+// Unallocated is the owner of contracts without an owner tag. It is empty, which no tag can name,
+// so that an owner tagged "(unallocated)" is not mixed with it.
+const Unallocated = ""
+
+// PriceTable is the prices used to compute costs. All prices are monthly and in the same currency.
+type PriceTable struct {
+	Currency string `json:"currency"`
+	// Plans are the prices of contracts by plan
+	Plans []PlanPrice `json:"plans"`
+	// DataPerGB is the price of a GB used in data pools
+	DataPerGB float64 `json:"data_per_gb"`
+	// SnapshotPerGB is the price of a GB used in snapshot pools
+	SnapshotPerGB float64 `json:"snapshot_per_gb"`
+}
+
+// PlanPrice is the price of a contract of a plan.
+type PlanPrice struct {
+	PlanID  int64   `json:"plan_id"`
+	Monthly float64 `json:"monthly"`
+}
+
+// LoadPriceTable reads a price table from a JSON file.
+func LoadPriceTable(path string) (*PriceTable, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	var prices PriceTable
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return nil, fmt.Errorf("invalid price table %s: %w", path, err)
+	}
+	return &prices, nil
+}
+
+func (p *PriceTable) planPrice(planID int64) (float64, bool) {
+	for _, price := range p.Plans {
+		if price.PlanID == planID {
+			return price.Monthly, true
+		}
+	}
+	return 0, false
+}
+
+// Line is the share of a contract allocated to an owner.
+type Line struct {
+	Owner        string `json:"owner"`
+	ContractID   int64  `json:"contract_id"`
+	ContractName string `json:"contract_name"`
+	PlanID       int64  `json:"plan_id"`
+	PlanName     string `json:"plan_name"`
+	// Share is the fraction of the contract allocated to the owner, split evenly between its owners.
+	// The costs of the contract are rounded to the hundredth before they are split, and the hundredths
+	// left over by the split go to the owners with the largest remainders, so the lines of a contract
+	// add up to its cost.
+	Share          float64 `json:"share"`
+	DataUsedGB     float64 `json:"data_used_gb"`
+	SnapshotUsedGB float64 `json:"snapshot_used_gb"`
+	PlanCost       float64 `json:"plan_cost"`
+	DataCost       float64 `json:"data_cost"`
+	SnapshotCost   float64 `json:"snapshot_cost"`
+	Total          float64 `json:"total"`
+}
+
+// OwnerTotal is the sum of the lines of an owner.
+type OwnerTotal struct {
+	Owner          string  `json:"owner"`
+	Contracts      int     `json:"contracts"`
+	DataUsedGB     float64 `json:"data_used_gb"`
+	SnapshotUsedGB float64 `json:"snapshot_used_gb"`
+	Total          float64 `json:"total"`
+}
+
+// Report is the result of Allocator.Allocate.
+type Report struct {
+	// Month is the month the report is for, formatted as "2006-01"
+	Month string `json:"month"`
+	// UsageAt is when the pool usage was read. Usage is a point-in-time reading, whatever the month.
+	UsageAt     time.Time `json:"usage_at"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Currency    string    `json:"currency"`
+	TagKey      string    `json:"tag_key"`
+	// Lines are ordered by owner and contract ID
+	Lines []Line `json:"lines"`
+	// Owners are ordered by owner, with Unallocated first when any contract has no owner
+	Owners []OwnerTotal `json:"owners"`
+	Total  float64      `json:"total"`
+}
+
+// Allocator allocates the costs of all contracts to their owners.
+type Allocator struct {
+	Contracts model.ContractAPI
+	Prices    *PriceTable
+	// TagKey is the key of the tags naming the owner, as in "key=owner" or "key:owner".
+	// DefaultTagKey is used when empty.
+	TagKey string
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+}
+
+// Allocate reads the current pool usage of every contract and allocates its cost for the month.
+// Contracts with several owner tags are split evenly between the owners.
+//
+// The month only labels the report: the usage is read when Allocate is called, recorded in
+// Report.UsageAt, even for past months. Run it at the end of the month to bill that month.
+//
+// It fails with *dedicatedstorage.ValidationError when Prices is nil, and when the price table
+// has no price for the plan of a contract.
+func (a *Allocator) Allocate(ctx context.Context, month time.Time) (*Report, error) {
+	if a.Prices == nil {
+		return nil, &dedicatedstorage.ValidationError{Errors: []*dedicatedstorage.FieldError{{Field: "Prices", Message: "is required"}}}
+	}
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	tagKey := a.TagKey
+	if tagKey == "" {
+		tagKey = DefaultTagKey
+	}
+
+	contracts, err := a.Contracts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usageAt := now()
+	report := &Report{
+		Month:       month.Format("2006-01"),
+		UsageAt:     usageAt,
+		GeneratedAt: usageAt,
+		Currency:    a.Prices.Currency,
+		TagKey:      tagKey,
+		Lines:       []Line{},
+		Owners:      []OwnerTotal{},
+	}
+	for _, contract := range contracts {
+		planPrice, ok := a.Prices.planPrice(contract.Plan.ID)
+		if !ok {
+			return nil, fmt.Errorf("no price for plan %d of contract %d", contract.Plan.ID, contract.ID)
+		}
+		usage, err := a.Contracts.PoolUsage(ctx, contract.ID)
+		if err != nil {
+			return nil, fmt.Errorf("reading pool usage of contract %d: %w", contract.ID, err)
+		}
+
+		owners := ownersOf(contract.Tags, tagKey)
+		shares := make([]float64, len(owners))
+		for i := range shares {
+			shares[i] = 1 / float64(len(owners))
+		}
+		planCosts := splitCents(toCents(planPrice), shares)
+		dataCosts := splitCents(toCents(float64(usage.Data.UsedGB)*a.Prices.DataPerGB), shares)
+		snapshotCosts := splitCents(toCents(float64(usage.Snapshot.UsedGB)*a.Prices.SnapshotPerGB), shares)
+		for i, owner := range owners {
+			report.Lines = append(report.Lines, Line{
+				Owner:          owner,
+				ContractID:     contract.ID,
+				ContractName:   contract.Name,
+				PlanID:         contract.Plan.ID,
+				PlanName:       contract.Plan.Name,
+				Share:          shares[i],
+				DataUsedGB:     float64(usage.Data.UsedGB) * shares[i],
+				SnapshotUsedGB: float64(usage.Snapshot.UsedGB) * shares[i],
+				PlanCost:       fromCents(planCosts[i]),
+				DataCost:       fromCents(dataCosts[i]),
+				SnapshotCost:   fromCents(snapshotCosts[i]),
+				Total:          fromCents(planCosts[i] + dataCosts[i] + snapshotCosts[i]),
+			})
+		}
+	}
+
+	sort.SliceStable(report.Lines, func(i, j int) bool {
+		if report.Lines[i].Owner != report.Lines[j].Owner {
+			return report.Lines[i].Owner < report.Lines[j].Owner
+		}
+		return report.Lines[i].ContractID < report.Lines[j].ContractID
+	})
+	for _, line := range report.Lines {
+		if n := len(report.Owners); n == 0 || report.Owners[n-1].Owner != line.Owner {
+			report.Owners = append(report.Owners, OwnerTotal{Owner: line.Owner})
+		}
+		total := &report.Owners[len(report.Owners)-1]
+		total.Contracts++
+		total.DataUsedGB += line.DataUsedGB
+		total.SnapshotUsedGB += line.SnapshotUsedGB
+		total.Total = fromCents(toCents(total.Total) + toCents(line.Total))
+		report.Total = fromCents(toCents(report.Total) + toCents(line.Total))
+	}
+	return report, nil
+}
+
+// ownersOf returns the distinct owners named by the tags, or Unallocated when there is none
+func ownersOf(tags []string, key string) []string {
+	var owners []string
+	for _, tag := range tags {
+		for _, sep := range []string{"=", ":"} {
+			if owner, ok := strings.CutPrefix(tag, key+sep); ok && owner != "" {
+				if !slices.Contains(owners, owner) {
+					owners = append(owners, owner)
+				}
+				break
+			}
+		}
+	}
+	if len(owners) == 0 {
+		return []string{Unallocated}
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// toCents rounds a cost to the hundredth, the smallest unit shown in reports, and returns it in hundredths
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// splitCents splits cents by shares with the largest remainder method: each share gets the hundredths
+// its fraction covers, and the hundredths left over go one by one to the largest remainders, the
+// earlier shares first on ties. The parts add up to cents.
+func splitCents(cents int64, shares []float64) []int64 {
+	parts := make([]int64, len(shares))
+	remainders := make([]float64, len(shares))
+	left := cents
+	for i, share := range shares {
+		exact := float64(cents) * share
+		parts[i] = int64(math.Floor(exact))
+		remainders[i] = exact - float64(parts[i])
+		left -= parts[i]
+	}
+	order := make([]int, len(shares))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return remainders[order[i]] > remainders[order[j]] })
+	for i := 0; left > 0 && len(order) > 0; i = (i + 1) % len(order) {
+		parts[order[i]]++
+		left--
+	}
+	return parts
+}
@@ -0,0 +1,133 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chargeback
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/sacloud/dedicated-storage-api-go/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocator_Allocate(t *testing.T) {
+	assert := require.New(t)
+
+	usageAt := time.Date(2025, 6, 30, 23, 0, 0, 0, time.UTC)
+	store := fake.NewStore()
+	plan := v1.Plan{ID: 1, Name: "plan"}
+	usage := func(dataGB, snapshotGB int64) v1.PoolUsageResponsePoolUsage {
+		return v1.PoolUsageResponsePoolUsage{
+			DataPool:     v1.PoolUsageResponsePoolUsageDataPool{TotalGB: 1000, UsedGB: dataGB},
+			SnapshotPool: v1.PoolUsageResponsePoolUsageSnapshotPool{TotalGB: 500, UsedGB: snapshotGB},
+		}
+	}
+	payments := store.AddContract(v1.DedicatedStorageContract{Name: "payments", Plan: plan, Tags: []string{"team=payments", "env=prod"}})
+	store.SetPoolUsage(payments.ID, usage(100, 50))
+	shared := store.AddContract(v1.DedicatedStorageContract{Name: "shared", Plan: plan, Tags: []string{"team:payments", "team=search"}})
+	store.SetPoolUsage(shared.ID, usage(200, 0))
+	untagged := store.AddContract(v1.DedicatedStorageContract{Name: "untagged", Plan: plan})
+	store.SetPoolUsage(untagged.ID, usage(10, 10))
+
+	allocator := &Allocator{
+		Contracts: model.NewContractOp(fake.NewContractOp(store)),
+		Prices:    &PriceTable{Currency: "JPY", Plans: []PlanPrice{{PlanID: 1, Monthly: 10000}}, DataPerGB: 10, SnapshotPerGB: 5},
+		TagKey:    "team",
+		Now:       func() time.Time { return usageAt },
+	}
+	report, err := allocator.Allocate(t.Context(), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(err)
+	assert.Equal("2025-06", report.Month)
+	assert.Equal(usageAt, report.UsageAt)
+
+	assert.Len(report.Lines, 4)
+	assert.Equal([]OwnerTotal{
+		{Owner: Unallocated, Contracts: 1, DataUsedGB: 10, SnapshotUsedGB: 10, Total: 10150},
+		{Owner: "payments", Contracts: 2, DataUsedGB: 200, SnapshotUsedGB: 50, Total: 17250},
+		{Owner: "search", Contracts: 1, DataUsedGB: 100, SnapshotUsedGB: 0, Total: 6000},
+	}, report.Owners)
+	assert.EqualValues(33400, report.Total)
+
+	var buf bytes.Buffer
+	assert.NoError(report.Write(&buf, FormatCSV))
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(err)
+	assert.Len(records, 5)
+	assert.Equal([]string{
+		"2025-06", "2025-06-30T23:00:00Z", "payments", strconv.FormatInt(shared.ID, 10), "shared", "1", "plan", "0.5",
+		"100", "0", "5000.00", "1000.00", "0.00", "6000.00", "JPY",
+	}, records[3])
+
+	buf.Reset()
+	assert.NoError(report.Write(&buf, FormatJSON))
+	var decoded Report
+	assert.NoError(json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(report.Owners, decoded.Owners)
+	assert.True(usageAt.Equal(decoded.UsageAt))
+
+	t.Run("uneven split", func(t *testing.T) {
+		assert := require.New(t)
+		store := fake.NewStore()
+		contract := store.AddContract(v1.DedicatedStorageContract{
+			Plan: plan, Tags: []string{"team=a", "team=b", "team=c", "team=(unallocated)"},
+		})
+		store.SetPoolUsage(contract.ID, usage(1, 0))
+		store.SetPoolUsage(store.AddContract(v1.DedicatedStorageContract{Plan: plan}).ID, usage(0, 0))
+
+		allocator := &Allocator{
+			Contracts: model.NewContractOp(fake.NewContractOp(store)),
+			Prices:    &PriceTable{Plans: []PlanPrice{{PlanID: 1, Monthly: 100}}, DataPerGB: 0.1},
+			TagKey:    "team",
+		}
+		report, err := allocator.Allocate(t.Context(), time.Now())
+		assert.NoError(err)
+
+		// 100.10 split in four: the leftover hundredths go to the first owners
+		var lines []float64
+		sum := 0.0
+		for _, line := range report.Lines {
+			if line.ContractID == contract.ID {
+				lines = append(lines, line.Total)
+				sum += line.Total
+			}
+		}
+		assert.Equal([]float64{25.03, 25.03, 25.02, 25.02}, lines)
+		assert.InDelta(100.10, sum, 1e-9)
+		assert.InDelta(200.10, report.Total, 1e-9)
+
+		// the tagged owner is not mixed with the contract without owner
+		assert.Equal([]string{Unallocated, "(unallocated)", "a", "b", "c"},
+			[]string{report.Owners[0].Owner, report.Owners[1].Owner, report.Owners[2].Owner, report.Owners[3].Owner, report.Owners[4].Owner})
+		assert.Equal(1, report.Owners[0].Contracts)
+		assert.EqualValues(100, report.Owners[0].Total)
+	})
+
+	allocator.Prices.Plans = nil
+	_, err = allocator.Allocate(t.Context(), time.Now())
+	assert.Error(err)
+
+	allocator.Prices = nil
+	_, err = allocator.Allocate(t.Context(), time.Now())
+	var validationErr *dedicatedstorage.ValidationError
+	assert.True(errors.As(err, &validationErr))
+}
@@ -0,0 +1,99 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chargeback
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Format is an output format of reports.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+// Write writes the report in the format.
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatCSV:
+		return r.WriteCSV(w)
+	case FormatJSON, "":
+		return r.WriteJSON(w)
+	}
+	return fmt.Errorf("unsupported format: %q", format)
+}
+
+var reportHeader = []string{
+	"MONTH", "USAGE_AT", "OWNER", "CONTRACT_ID", "CONTRACT", "PLAN_ID", "PLAN", "SHARE",
+	"DATA_USED_GB", "SNAPSHOT_USED_GB", "PLAN_COST", "DATA_COST", "SNAPSHOT_COST", "TOTAL", "CURRENCY",
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatCost(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func (r *Report) columns(l *Line) []string {
+	return []string{
+		r.Month,
+		r.UsageAt.Format(time.RFC3339),
+		l.Owner,
+		strconv.FormatInt(l.ContractID, 10),
+		l.ContractName,
+		strconv.FormatInt(l.PlanID, 10),
+		l.PlanName,
+		formatFloat(l.Share),
+		formatFloat(l.DataUsedGB),
+		formatFloat(l.SnapshotUsedGB),
+		formatCost(l.PlanCost),
+		formatCost(l.DataCost),
+		formatCost(l.SnapshotCost),
+		formatCost(l.Total),
+		r.Currency,
+	}
+}
+
+// WriteCSV writes the lines as CSV with a header row. Every line records when the usage was read,
+// as the usage is a point-in-time reading rather than the usage over the month.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(reportHeader); err != nil {
+		return err
+	}
+	for i := range r.Lines {
+		if err := cw.Write(r.columns(&r.Lines[i])); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the whole report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,160 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command dedicated-storage-catalog exports the configuration of contracts and imports it back.
+//
+// Usage:
+//
+//	dedicated-storage-catalog export [-o path] [-format json|yaml] [-without-snapshots] [client flags]
+//	dedicated-storage-catalog diff path [client flags]
+//	dedicated-storage-catalog import [-dry-run] path [client flags]
+//
+// export writes the document to stdout unless -o is given, in the format of the extension of
+// the output file by default. diff prints the changes import would make without making them.
+// import prints the changes and makes them, it fails when some contracts conflict.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	"github.com/sacloud/dedicated-storage-api-go/catalog"
+	"github.com/sacloud/saclient-go"
+)
+
+var theClient saclient.Client
+
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "command is required: export, diff or import")
+		return errUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "export":
+		return export(ctx, args)
+	case "diff":
+		return importDocument(ctx, args, true)
+	case "import":
+		return importDocument(ctx, args, false)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", command)
+		return errUsage
+	}
+}
+
+func newContractAPI(apiRootURL string) (dedicatedstorage.ContractAPI, error) {
+	if err := theClient.SetEnviron(os.Environ()); err != nil {
+		return nil, fmt.Errorf("failed to set environment: %w", err)
+	}
+	client, err := dedicatedstorage.NewClientWithAPIRootURL(&theClient, apiRootURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return dedicatedstorage.NewContractOp(client), nil
+}
+
+func export(ctx context.Context, args []string) error {
+	fs := theClient.FlagSet(flag.ContinueOnError)
+	apiRootURL := fs.String("api-root-url", dedicatedstorage.DefaultAPIRootURL, "root URL of the dedicated storage API")
+	output := fs.String("o", "", "path to the output file, stdout when empty")
+	format := fs.String("format", "", "format of the document, json or yaml (default by the extension of the output file)")
+	withoutSnapshots := fs.Bool("without-snapshots", false, "leave the snapshot catalog out of the document")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *format == "" {
+		*format = string(catalog.FormatOf(*output))
+	}
+
+	api, err := newContractAPI(*apiRootURL)
+	if err != nil {
+		return err
+	}
+	doc, err := catalog.Export(ctx, api, &catalog.ExportOptions{WithoutSnapshots: *withoutSnapshots})
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return doc.Write(os.Stdout, catalog.Format(*format))
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := doc.Write(f, catalog.Format(*format)); err != nil {
+		f.Close() //nolint:errcheck
+		return err
+	}
+	return f.Close()
+}
+
+func importDocument(ctx context.Context, args []string, dryRun bool) error {
+	fs := theClient.FlagSet(flag.ContinueOnError)
+	apiRootURL := fs.String("api-root-url", dedicatedstorage.DefaultAPIRootURL, "root URL of the dedicated storage API")
+	if !dryRun {
+		fs.BoolVar(&dryRun, "dry-run", false, "print the changes without making them")
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "path to the document is required")
+		return errUsage
+	}
+
+	doc, err := catalog.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	api, err := newContractAPI(*apiRootURL)
+	if err != nil {
+		return err
+	}
+
+	importer := catalog.NewImporter(api)
+	if dryRun {
+		changes, err := importer.Preview(ctx, doc)
+		if err != nil {
+			return err
+		}
+		return catalog.WriteChanges(os.Stdout, changes)
+	}
+	changes, applyErr := importer.Apply(ctx, doc)
+	if err := catalog.WriteChanges(os.Stdout, changes); err != nil {
+		return err
+	}
+	return applyErr
+}
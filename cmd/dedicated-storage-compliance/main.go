@@ -0,0 +1,103 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command dedicated-storage-compliance audits the encryption of disks on dedicated storage.
+//
+// It writes the report as JSON to stdout and exits with 1 when any disk violates the policy,
+// or with 2 when the audit itself fails.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	"github.com/sacloud/dedicated-storage-api-go/compliance"
+	"github.com/sacloud/saclient-go"
+)
+
+const (
+	exitNonCompliant = 1
+	exitError        = 2
+)
+
+var theClient saclient.Client
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	fs := theClient.FlagSet(flag.ContinueOnError)
+	policyPath := fs.String("policy", "", "path to the policy JSON file (required)")
+	apiRootURL := fs.String("api-root-url", dedicatedstorage.DefaultAPIRootURL, "root URL of the dedicated storage API")
+	signer := fs.String("signer", "", "name recorded as the signer of the report")
+	signKeyEnv := fs.String("sign-key-env", "", "name of the environment variable holding the report signing key")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return exitError
+	}
+	if *policyPath == "" {
+		fmt.Fprintln(os.Stderr, "-policy is required")
+		fs.Usage()
+		return exitError
+	}
+
+	policy, err := compliance.LoadPolicy(*policyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load policy: %v\n", err)
+		return exitError
+	}
+	if err := theClient.SetEnviron(os.Environ()); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to set environment: %v\n", err)
+		return exitError
+	}
+	client, err := dedicatedstorage.NewClientWithAPIRootURL(&theClient, *apiRootURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create client: %v\n", err)
+		return exitError
+	}
+
+	auditor := &compliance.Auditor{Contracts: dedicatedstorage.NewContractOp(client), Policy: *policy}
+	report, err := auditor.Audit(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "audit failed: %v\n", err)
+		return exitError
+	}
+
+	if *signKeyEnv != "" {
+		key := os.Getenv(*signKeyEnv)
+		if err := report.Sign(*signer, []byte(key), time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to sign report: %v\n", err)
+			return exitError
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+		return exitError
+	}
+
+	violations := report.Violations()
+	fmt.Fprintf(os.Stderr, "%d disks audited, %d non-compliant\n", len(report.Disks), len(violations))
+	if !report.Compliant {
+		return exitNonCompliant
+	}
+	return 0
+}
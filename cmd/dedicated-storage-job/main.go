@@ -0,0 +1,195 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command dedicated-storage-job submits disk operations as jobs and runs them.
+//
+// Usage:
+//
+//	dedicated-storage-job [-store path] snapshot -disk ID -contract ID -name NAME [-description TEXT]
+//	dedicated-storage-job [-store path] run [-concurrency N] [client flags]
+//	dedicated-storage-job [-store path] status ID
+//	dedicated-storage-job [-store path] list
+//
+// snapshot only records the job. run submits the jobs to the API and waits for their completion,
+// resuming the jobs left unfinished by a previous run. Jobs are written to stdout as JSON.
+//
+// Restore and expand jobs are waited for by reading the disk itself, which this command cannot do:
+// it refuses to submit them, and run fails those submitted through the job package without calling
+// the API. Use a job.Runner with a StateReader such as iaas.Integration for them.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/gofrs/flock"
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	"github.com/sacloud/dedicated-storage-api-go/job"
+	"github.com/sacloud/saclient-go"
+)
+
+var theClient saclient.Client
+
+// errUsage is returned when the arguments are invalid, after the usage is printed
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
+
+func defaultStorePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "dedicated-storage", "jobs.json")
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("dedicated-storage-job", flag.ContinueOnError)
+	storePath := fs.String("store", defaultStorePath(), "path to the job store file")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "command is required: snapshot, run, status or list")
+		return errUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	store := job.NewFileStore(*storePath)
+	command, args := fs.Arg(0), fs.Args()[1:]
+	switch command {
+	case "snapshot":
+		return submit(ctx, store, job.KindSnapshot, args)
+	case "restore", "expand":
+		return fmt.Errorf("%s jobs cannot be run by this command: %w", command, dedicatedstorage.ErrNoDiskStateReader)
+	case "run":
+		return runJobs(ctx, store, args)
+	case "status":
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "usage: status ID")
+			return errUsage
+		}
+		j, err := store.Get(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return writeJSON(j)
+	case "list":
+		jobs, err := store.List(ctx)
+		if err != nil {
+			return err
+		}
+		return writeJSON(jobs)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", command)
+		return errUsage
+	}
+}
+
+func submit(ctx context.Context, store job.Store, kind job.Kind, args []string) error {
+	spec := &job.Spec{Kind: kind}
+	fs := flag.NewFlagSet(string(kind), flag.ContinueOnError)
+	fs.Int64Var(&spec.DiskID, "disk", 0, "ID of the disk (required)")
+	fs.Int64Var(&spec.ContractID, "contract", 0, "ID of the contract storing the snapshot (required)")
+	fs.StringVar(&spec.Name, "name", "", "name of the snapshot (required)")
+	fs.StringVar(&spec.Description, "description", "", "description of the snapshot")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	// submitting only records the job, no API client is needed
+	j, err := job.NewRunner(store, nil).Submit(ctx, spec)
+	if err != nil {
+		return err
+	}
+	return writeJSON(j)
+}
+
+func runJobs(ctx context.Context, store *job.FileStore, args []string) error {
+	fs := theClient.FlagSet(flag.ContinueOnError)
+	apiRootURL := fs.String("api-root-url", dedicatedstorage.DefaultAPIRootURL, "root URL of the dedicated storage API")
+	concurrency := fs.Int("concurrency", job.DefaultConcurrency, "maximum number of jobs run at the same time")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	// a store must be run by a single runner at a time
+	if err := os.MkdirAll(filepath.Dir(store.Path), 0o700); err != nil {
+		return err
+	}
+	fl := flock.New(store.Path + ".run.lock")
+	locked, err := fl.TryLock()
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return fmt.Errorf("another run is in progress on %s", store.Path)
+	}
+	defer fl.Unlock() //nolint:errcheck
+
+	if err := theClient.SetEnviron(os.Environ()); err != nil {
+		return fmt.Errorf("failed to set environment: %w", err)
+	}
+	client, err := dedicatedstorage.NewClientWithAPIRootURL(&theClient, *apiRootURL)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	runner := job.NewRunner(store, dedicatedstorage.NewDiskOp(client))
+	runner.Concurrency = *concurrency
+	finished, runErr := runner.Run(ctx)
+
+	jobs, err := store.List(context.WithoutCancel(ctx))
+	if err != nil {
+		return errors.Join(runErr, err)
+	}
+	// jobs which failed in earlier runs stay in the store and are not counted again
+	failed := 0
+	for _, j := range finished {
+		if j.State == job.StateFailed {
+			failed++
+		}
+	}
+	if err := writeJSON(jobs); err != nil {
+		return errors.Join(runErr, err)
+	}
+	if runErr != nil {
+		return runErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d jobs failed", failed)
+	}
+	return nil
+}
+
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
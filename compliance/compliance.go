@@ -0,0 +1,180 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compliance audits the encryption of disks on dedicated storage against a policy.
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+)
+
+// Policy is the set of rules disks must comply with.
+type Policy struct {
+	// AllowedAlgorithms are the permitted encryption algorithms. Any algorithm other than "none" is permitted when empty.
+	AllowedAlgorithms []types.EncryptionAlgorithm `json:"allowed_algorithms,omitempty"`
+	// AllowedKMSKeyIDs are the permitted KMS keys. Any key is permitted when empty.
+	AllowedKMSKeyIDs []int64 `json:"allowed_kms_key_ids,omitempty"`
+	// RequireKMSKey requires disks to be encrypted with a KMS key.
+	RequireKMSKey bool `json:"require_kms_key,omitempty"`
+}
+
+// LoadPolicy reads a policy from a JSON file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// Check returns the violations of the disk against the policy.
+func (p *Policy) Check(disk *v1.Disk) []string {
+	var violations []string
+
+	algorithm := types.EncryptionAlgorithm(strings.ToLower(disk.EncryptionAlgorithm))
+	switch {
+	case !algorithm.IsEncrypted():
+		violations = append(violations, "disk is not encrypted")
+	case len(p.AllowedAlgorithms) > 0 && !slices.ContainsFunc(p.AllowedAlgorithms, func(v types.EncryptionAlgorithm) bool {
+		return strings.EqualFold(string(v), string(algorithm))
+	}):
+		violations = append(violations, fmt.Sprintf("encryption algorithm %q is not allowed", disk.EncryptionAlgorithm))
+	}
+
+	keyID := dedicatedstorage.DiskKMSKeyID(disk)
+	hasKey := keyID != 0
+	if p.RequireKMSKey && !hasKey {
+		violations = append(violations, "disk is not encrypted with a KMS key")
+	}
+	if hasKey && len(p.AllowedKMSKeyIDs) > 0 && !slices.Contains(p.AllowedKMSKeyIDs, keyID) {
+		violations = append(violations, fmt.Sprintf("KMS key %d is not allowed", keyID))
+	}
+	return violations
+}
+
+// DiskResult is the audit result of a disk.
+type DiskResult struct {
+	DiskID              int64                     `json:"disk_id"`
+	DiskName            string                    `json:"disk_name"`
+	ContractIDs         []int64                   `json:"contract_ids"`
+	EncryptionAlgorithm types.EncryptionAlgorithm `json:"encryption_algorithm"`
+	KMSKeyID            int64                     `json:"kms_key_id,omitempty"`
+	Compliant           bool                      `json:"compliant"`
+	Violations          []string                  `json:"violations,omitempty"`
+}
+
+// Report is the result of Auditor.Audit.
+type Report struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Policy      Policy       `json:"policy"`
+	Compliant   bool         `json:"compliant"`
+	Disks       []DiskResult `json:"disks"`
+	Signature   *Signature   `json:"signature,omitempty"`
+}
+
+// Violations returns the results of non-compliant disks.
+func (r *Report) Violations() []DiskResult {
+	results := []DiskResult{}
+	for _, d := range r.Disks {
+		if !d.Compliant {
+			results = append(results, d)
+		}
+	}
+	return results
+}
+
+// Auditor checks every disk on dedicated storage against the policy.
+//
+// Disks are enumerated from the snapshots of all contracts, so disks without any snapshot are not audited.
+// The state of each disk is taken from its most recent snapshot.
+type Auditor struct {
+	Contracts dedicatedstorage.ContractAPI
+	Policy    Policy
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+}
+
+// Audit enumerates the disks and checks each of them against the policy.
+func (a *Auditor) Audit(ctx context.Context) (*Report, error) {
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+
+	contracts, err := a.Contracts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type seenDisk struct {
+		disk        v1.Disk
+		observedAt  time.Time
+		contractIDs []int64
+	}
+	disks := make(map[int64]*seenDisk)
+	for _, contract := range contracts.DedicatedStorageContracts {
+		snapshots, err := a.Contracts.ListDiskSnapshots(ctx, contract.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, snapshot := range snapshots.DiskSnapshots {
+			seen, ok := disks[snapshot.Disk.ID]
+			if !ok {
+				seen = &seenDisk{}
+				disks[snapshot.Disk.ID] = seen
+			}
+			if !ok || snapshot.CreatedAt.After(seen.observedAt) {
+				seen.disk = snapshot.Disk
+				seen.observedAt = snapshot.CreatedAt
+			}
+			if !slices.Contains(seen.contractIDs, contract.ID) {
+				seen.contractIDs = append(seen.contractIDs, contract.ID)
+			}
+		}
+	}
+
+	report := &Report{GeneratedAt: now(), Policy: a.Policy, Compliant: true, Disks: []DiskResult{}}
+	for _, seen := range disks {
+		violations := a.Policy.Check(&seen.disk)
+		report.Disks = append(report.Disks, DiskResult{
+			DiskID:              seen.disk.ID,
+			DiskName:            seen.disk.Name,
+			ContractIDs:         seen.contractIDs,
+			EncryptionAlgorithm: types.EncryptionAlgorithm(seen.disk.EncryptionAlgorithm),
+			KMSKeyID:            dedicatedstorage.DiskKMSKeyID(&seen.disk),
+			Compliant:           len(violations) == 0,
+			Violations:          violations,
+		})
+		if len(violations) > 0 {
+			report.Compliant = false
+		}
+	}
+	sort.Slice(report.Disks, func(i, j int) bool { return report.Disks[i].DiskID < report.Disks[j].DiskID })
+	return report, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compliance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func encryptedDisk(name, algorithm string, keyID int64) v1.Disk {
+	disk := v1.Disk{Name: name, EncryptionAlgorithm: algorithm, EncryptionKey: v1.NilDiskEncryptionKey{Null: true}}
+	if keyID != 0 {
+		disk.EncryptionKey = v1.NewNilDiskEncryptionKey(v1.DiskEncryptionKey{KMSKeyID: v1.NewNilInt64(keyID)})
+	}
+	return disk
+}
+
+func TestPolicy_Check(t *testing.T) {
+	policy := Policy{AllowedAlgorithms: []types.EncryptionAlgorithm{types.EncryptionAlgorithmAES256XTS}, AllowedKMSKeyIDs: []int64{1}, RequireKMSKey: true}
+
+	tests := []struct {
+		name string
+		disk v1.Disk
+		want int
+	}{
+		{name: "compliant", disk: encryptedDisk("d", "aes256_xts", 1), want: 0},
+		{name: "none", disk: encryptedDisk("d", "none", 0), want: 2},
+		{name: "algorithm", disk: encryptedDisk("d", "aes128", 1), want: 1},
+		{name: "key", disk: encryptedDisk("d", "aes256_xts", 2), want: 1},
+		{name: "no key", disk: encryptedDisk("d", "aes256_xts", 0), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.New(t).Len(policy.Check(&tt.disk), tt.want)
+		})
+	}
+
+	// "none" is never allowed even by an empty policy
+	disk := encryptedDisk("d", "none", 0)
+	require.New(t).Len((&Policy{}).Check(&disk), 1)
+}
+
+func TestAuditor_Audit(t *testing.T) {
+	assert := require.New(t)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	good := store.AddDisk(encryptedDisk("good", "aes256_xts", 1))
+	bad := store.AddDisk(encryptedDisk("bad", "none", 0))
+
+	for i, disk := range []v1.Disk{good, bad, good} {
+		store.AddSnapshot(v1.DiskSnapshot{
+			CreatedAt:                now.Add(time.Duration(i) * time.Hour),
+			Disk:                     disk,
+			DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+		})
+	}
+
+	auditor := &Auditor{Contracts: fake.NewContractOp(store), Policy: Policy{AllowedKMSKeyIDs: []int64{1}}, Now: func() time.Time { return now }}
+	report, err := auditor.Audit(t.Context())
+	assert.NoError(err)
+	assert.False(report.Compliant)
+	assert.Len(report.Disks, 2)
+	assert.Len(report.Violations(), 1)
+	assert.Equal(bad.ID, report.Violations()[0].DiskID)
+
+	key := []byte("secret")
+	assert.NoError(report.Sign("security-team", key, now))
+	assert.True(report.Verify(key))
+	assert.False(report.Verify([]byte("other")))
+
+	data, err := json.Marshal(report)
+	assert.NoError(err)
+	var decoded Report
+	assert.NoError(json.Unmarshal(data, &decoded))
+	assert.True(decoded.Verify(key))
+
+	decoded.Compliant = true
+	assert.False(decoded.Verify(key))
+}
+
+func TestLoadPolicy(t *testing.T) {
+	assert := require.New(t)
+	path := filepath.Join(t.TempDir(), "policy.json")
+	assert.NoError(os.WriteFile(path, []byte(`{"allowed_algorithms":["aes256_xts"],"require_kms_key":true}`), 0o600))
+
+	policy, err := LoadPolicy(path)
+	assert.NoError(err)
+	assert.Equal(&Policy{AllowedAlgorithms: []types.EncryptionAlgorithm{types.EncryptionAlgorithmAES256XTS}, RequireKMSKey: true}, policy)
+}
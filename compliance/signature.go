@@ -0,0 +1,81 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compliance
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const SignatureAlgorithm = "HMAC-SHA256"
+
+// Signature signs off a report.
+type Signature struct {
+	SignedBy  string    `json:"signed_by"`
+	SignedAt  time.Time `json:"signed_at"`
+	Algorithm string    `json:"algorithm"`
+	// Value is the hex encoded MAC of the report without Value itself
+	Value string `json:"value"`
+}
+
+// Sign signs off the report by signer with the key.
+func (r *Report) Sign(signer string, key []byte, signedAt time.Time) error {
+	if len(key) == 0 {
+		return errors.New("signing key is required")
+	}
+	r.Signature = &Signature{SignedBy: signer, SignedAt: signedAt, Algorithm: SignatureAlgorithm}
+	mac, err := r.mac(key)
+	if err != nil {
+		r.Signature = nil
+		return err
+	}
+	r.Signature.Value = hex.EncodeToString(mac)
+	return nil
+}
+
+// Verify reports whether the report is signed with the key and unchanged since.
+func (r *Report) Verify(key []byte) bool {
+	if r.Signature == nil || r.Signature.Algorithm != SignatureAlgorithm {
+		return false
+	}
+	signed, err := hex.DecodeString(r.Signature.Value)
+	if err != nil {
+		return false
+	}
+	mac, err := r.mac(key)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(signed, mac)
+}
+
+func (r *Report) mac(key []byte) ([]byte, error) {
+	unsigned := *r
+	sig := *r.Signature
+	sig.Value = ""
+	unsigned.Signature = &sig
+
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(data) //nolint:errcheck,gosec
+	return h.Sum(nil), nil
+}
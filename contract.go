@@ -48,11 +48,15 @@ func NewContractOp(client *v1.Client) ContractAPI {
 func (op *contractOp) Create(ctx context.Context, req v1.CreateDedicatedStorageContractRequest) (*v1.DedicatedStorageContract, error) {
 	const methodName = "Contract.Create"
 
+	if err := ValidateCreateContractRequest(&req); err != nil {
+		return nil, NewError(methodName, err)
+	}
+
 	res, err := op.client.DedicatedStorageContractsCreate(ctx, &req)
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return nil, NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return nil, newAPIErrorFromStatusCode(methodName, e)
 		}
 		return nil, NewAPIError(methodName, 0, err)
 	}
@@ -67,7 +71,7 @@ func (op *contractOp) List(ctx context.Context) (*v1.DedicatedStorageContractsLi
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return nil, NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return nil, newAPIErrorFromStatusCode(methodName, e)
 		}
 		return nil, NewAPIError(methodName, 0, err)
 	}
@@ -82,7 +86,7 @@ func (op *contractOp) Read(ctx context.Context, id int64) (*v1.DedicatedStorageC
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return nil, NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return nil, newAPIErrorFromStatusCode(methodName, e)
 		}
 		return nil, NewAPIError(methodName, 0, err)
 	}
@@ -93,11 +97,15 @@ func (op *contractOp) Read(ctx context.Context, id int64) (*v1.DedicatedStorageC
 func (op *contractOp) Update(ctx context.Context, id int64, request v1.UpdateDedicatedStorageContractRequest) (*v1.DedicatedStorageContract, error) {
 	const methodName = "Contract.Update"
 
+	if err := ValidateUpdateContractRequest(id, &request); err != nil {
+		return nil, NewError(methodName, err)
+	}
+
 	res, err := op.client.DedicatedStorageContractsUpdate(ctx, &request, v1.DedicatedStorageContractsUpdateParams{ID: id})
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return nil, NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return nil, newAPIErrorFromStatusCode(methodName, e)
 		}
 		return nil, NewAPIError(methodName, 0, err)
 	}
@@ -112,7 +120,7 @@ func (op *contractOp) Delete(ctx context.Context, id int64) error {
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return newAPIErrorFromStatusCode(methodName, e)
 		}
 		return NewAPIError(methodName, 0, err)
 	}
@@ -127,7 +135,7 @@ func (op *contractOp) PoolUsage(ctx context.Context, id int64) (*v1.PoolUsageRes
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return nil, NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return nil, newAPIErrorFromStatusCode(methodName, e)
 		}
 		return nil, NewAPIError(methodName, 0, err)
 	}
@@ -143,7 +151,7 @@ func (op *contractOp) ListDiskSnapshots(ctx context.Context, id int64) (*v1.Disk
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return nil, NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return nil, newAPIErrorFromStatusCode(methodName, e)
 		}
 		return nil, NewAPIError(methodName, 0, err)
 	}
@@ -158,7 +166,7 @@ func (op *contractOp) ListPlans(ctx context.Context) (*v1.DedicatedStorageContra
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return nil, NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return nil, newAPIErrorFromStatusCode(methodName, e)
 		}
 		return nil, NewAPIError(methodName, 0, err)
 	}
@@ -172,7 +180,7 @@ func (op *contractOp) ReadPlan(ctx context.Context, planID int64) (*v1.Dedicated
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return nil, NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return nil, newAPIErrorFromStatusCode(methodName, e)
 		}
 		return nil, NewAPIError(methodName, 0, err)
 	}
@@ -0,0 +1,198 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/packages-go/wait"
+)
+
+// ErrContractHasSnapshots is returned when a contract to delete still has snapshots and cascade is not requested.
+var ErrContractHasSnapshots = errors.New("contract has snapshots")
+
+// DeleteContractOptions configures ContractDeleteAPI.DeleteContract.
+type DeleteContractOptions struct {
+	// Cascade deletes the snapshots of the contract before deleting the contract
+	Cascade bool
+	// Concurrency is the number of snapshots deleted at the same time. 1 is used when zero or less.
+	Concurrency int
+	// Progress is called after each snapshot deletion, successful or not. Calls are never concurrent.
+	Progress func(progress DeleteContractProgress)
+	// WaitInterval is the polling interval while waiting for the snapshots to be deleted.
+	// DefaultSnapshotWaitInterval is used when zero.
+	WaitInterval time.Duration
+	// WaitTimeout is the maximum time to wait for the snapshots to be deleted.
+	// DefaultSnapshotWaitTimeout is used when zero.
+	WaitTimeout time.Duration
+}
+
+// DeleteContractProgress reports the deletion of a snapshot during a cascading contract deletion.
+type DeleteContractProgress struct {
+	Snapshot *v1.DiskSnapshot
+	Err      error
+	// Done is the number of snapshots processed so far, including this one
+	Done  int
+	Total int
+}
+
+// DeleteContractSummary is everything removed by ContractDeleteAPI.DeleteContract.
+type DeleteContractSummary struct {
+	ContractID int64
+	// DeletedSnapshots are the snapshots deleted, in the order they were listed
+	DeletedSnapshots []v1.DiskSnapshot
+	// FailedSnapshots are the snapshots which could not be deleted
+	FailedSnapshots []v1.DiskSnapshot
+	// ContractDeleted reports whether the contract itself was deleted
+	ContractDeleted bool
+}
+
+type ContractDeleteAPI interface {
+	DeleteContract(ctx context.Context, id int64, opts *DeleteContractOptions) (*DeleteContractSummary, error)
+}
+
+var _ ContractDeleteAPI = (*contractDeleteOp)(nil)
+
+type contractDeleteOp struct {
+	contract ContractAPI
+	disk     DiskAPI
+}
+
+// NewContractDeleteOp returns a ContractDeleteAPI.
+func NewContractDeleteOp(contract ContractAPI, disk DiskAPI) ContractDeleteAPI {
+	return &contractDeleteOp{contract: contract, disk: disk}
+}
+
+// DeleteContract deletes the contract after checking its snapshots.
+//
+// It refuses with ErrContractHasSnapshots when any snapshot exists, unless opts.Cascade is set.
+// With cascade, the snapshots are deleted first and the contract is kept when any of them fails.
+// Snapshots are deleted asynchronously, so the contract is deleted once it lists no snapshot anymore.
+// The summary is returned along with the error so that partial deletions can be inspected.
+func (op *contractDeleteOp) DeleteContract(ctx context.Context, id int64, opts *DeleteContractOptions) (*DeleteContractSummary, error) {
+	const methodName = "Contract.DeleteContract"
+
+	if opts == nil {
+		opts = &DeleteContractOptions{}
+	}
+	summary := &DeleteContractSummary{ContractID: id}
+
+	res, err := op.contract.ListDiskSnapshots(ctx, id)
+	if err != nil {
+		return summary, NewError(methodName, err)
+	}
+	snapshots := res.DiskSnapshots
+	if len(snapshots) > 0 && !opts.Cascade {
+		return summary, NewError(methodName, fmt.Errorf("%w: %d snapshots remain in contract %d", ErrContractHasSnapshots, len(snapshots), id))
+	}
+
+	if err := op.deleteSnapshots(ctx, snapshots, opts, summary); err != nil {
+		return summary, NewError(methodName, err)
+	}
+	if len(snapshots) > 0 {
+		if err := op.waitForSnapshotsDeleted(ctx, id, opts); err != nil {
+			return summary, NewError(methodName, err)
+		}
+	}
+
+	if err := op.contract.Delete(ctx, id); err != nil {
+		return summary, NewError(methodName, err)
+	}
+	summary.ContractDeleted = true
+	return summary, nil
+}
+
+// waitForSnapshotsDeleted polls the snapshots of the contract until none is listed
+func (op *contractDeleteOp) waitForSnapshotsDeleted(ctx context.Context, id int64, opts *DeleteContractOptions) error {
+	interval := opts.WaitInterval
+	if interval <= 0 {
+		interval = DefaultSnapshotWaitInterval
+	}
+	timeout := opts.WaitTimeout
+	if timeout <= 0 {
+		timeout = DefaultSnapshotWaitTimeout
+	}
+
+	waiter := &wait.PollingWaiter{
+		ReadFunc: func() (interface{}, error) {
+			return op.contract.ListDiskSnapshots(ctx, id)
+		},
+		StateCheckFunc: func(target interface{}) (bool, error) {
+			return len(target.(*v1.DiskSnapshotsListResponse).DiskSnapshots) == 0, nil
+		},
+		Interval: interval,
+		Timeout:  timeout,
+	}
+	if _, err := waiter.WaitForState(ctx); err != nil {
+		return fmt.Errorf("waiting for the snapshots of contract %d to be deleted: %w", id, err)
+	}
+	return nil
+}
+
+func (op *contractDeleteOp) deleteSnapshots(ctx context.Context, snapshots []v1.DiskSnapshot, opts *DeleteContractOptions, summary *DeleteContractSummary) error {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	errs := make([]error, len(snapshots))
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	sem := make(chan struct{}, concurrency)
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+			} else {
+				errs[i] = op.disk.DeleteSnapshot(ctx, snapshot.Disk.ID, snapshot.ID)
+			}
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("deleting snapshot %d of disk %d: %w", snapshot.ID, snapshot.Disk.ID, errs[i])
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if opts.Progress != nil {
+				opts.Progress(DeleteContractProgress{Snapshot: snapshot, Err: errs[i], Done: done, Total: len(snapshots)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range snapshots {
+		if errs[i] != nil {
+			summary.FailedSnapshots = append(summary.FailedSnapshots, snapshots[i])
+		} else {
+			summary.DeletedSnapshots = append(summary.DeletedSnapshots, snapshots[i])
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,104 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractDeleteOp_DeleteContract(t *testing.T) {
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	empty := store.AddContract(v1.DedicatedStorageContract{})
+	disk := store.AddDisk(v1.Disk{})
+	for range 5 {
+		store.AddSnapshot(v1.DiskSnapshot{
+			SnapshotState:            "available",
+			Disk:                     disk,
+			DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+		})
+	}
+
+	op := dedicatedstorage.NewContractDeleteOp(fake.NewContractOp(store), fake.NewDiskOp(store))
+
+	t.Run("empty", func(t *testing.T) {
+		assert := require.New(t)
+		summary, err := op.DeleteContract(t.Context(), empty.ID, nil)
+		assert.NoError(err)
+		assert.True(summary.ContractDeleted)
+		assert.Empty(summary.DeletedSnapshots)
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		assert := require.New(t)
+		summary, err := op.DeleteContract(t.Context(), contract.ID, nil)
+		assert.True(errors.Is(err, dedicatedstorage.ErrContractHasSnapshots))
+		assert.False(summary.ContractDeleted)
+		assert.Len(store.Snapshots(), 5)
+	})
+
+	t.Run("cascade failure keeps contract", func(t *testing.T) {
+		assert := require.New(t)
+		store.Fail("Disk.DeleteSnapshot", errors.New("busy"))
+		defer store.Fail("Disk.DeleteSnapshot", nil)
+
+		summary, err := op.DeleteContract(t.Context(), contract.ID, &dedicatedstorage.DeleteContractOptions{Cascade: true})
+		assert.Error(err)
+		assert.False(summary.ContractDeleted)
+		assert.Len(summary.FailedSnapshots, 5)
+		_, ok := store.Contract(contract.ID)
+		assert.True(ok)
+	})
+
+	t.Run("cascade", func(t *testing.T) {
+		assert := require.New(t)
+		// the deleted snapshots remain listed for a while, the contract cannot be deleted meanwhile
+		store.DeletingReads = 3
+		defer func() { store.DeletingReads = 0 }()
+		var progress []dedicatedstorage.DeleteContractProgress
+		summary, err := op.DeleteContract(t.Context(), contract.ID, &dedicatedstorage.DeleteContractOptions{
+			Cascade:     true,
+			Concurrency: 3,
+			Progress: func(p dedicatedstorage.DeleteContractProgress) {
+				progress = append(progress, p)
+			},
+			WaitInterval: time.Millisecond,
+		})
+		assert.NoError(err)
+		assert.True(summary.ContractDeleted)
+		assert.Len(summary.DeletedSnapshots, 5)
+		assert.Empty(summary.FailedSnapshots)
+		assert.Len(progress, 5)
+		assert.Equal(5, progress[4].Done)
+		assert.Empty(store.Snapshots())
+		_, ok := store.Contract(contract.ID)
+		assert.False(ok)
+
+		// three listings in the deleting state and an empty one
+		calls := store.Calls()
+		last := len(calls) - 1
+		for calls[last] != "Disk.DeleteSnapshot" {
+			last--
+		}
+		assert.Equal([]string{"Contract.DiskSnapshots", "Contract.DiskSnapshots", "Contract.DiskSnapshots", "Contract.DiskSnapshots", "Contract.Delete"}, calls[last+1:])
+	})
+}
@@ -45,11 +45,15 @@ func NewDiskOp(client *v1.Client) DiskAPI {
 func (op *diskOp) CreateSnapshot(ctx context.Context, diskID int64, request *v1.CreateSnapshotRequest) (*v1.DiskSnapshot, error) {
 	const methodName = "Disk.CreateSnapshot"
 
+	if err := ValidateCreateSnapshotRequest(diskID, request); err != nil {
+		return nil, NewError(methodName, err)
+	}
+
 	res, err := op.client.DisksCreateSnapshot(ctx, request, v1.DisksCreateSnapshotParams{DiskId: diskID})
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return nil, NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return nil, newAPIErrorFromStatusCode(methodName, e)
 		}
 		return nil, NewAPIError(methodName, 0, err)
 	}
@@ -64,7 +68,7 @@ func (op *diskOp) ListSnapshots(ctx context.Context, diskID int64) (*v1.DiskSnap
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return nil, NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return nil, newAPIErrorFromStatusCode(methodName, e)
 		}
 		return nil, NewAPIError(methodName, 0, err)
 	}
@@ -75,11 +79,15 @@ func (op *diskOp) ListSnapshots(ctx context.Context, diskID int64) (*v1.DiskSnap
 func (op *diskOp) UpdateSnapshot(ctx context.Context, diskID, snapshotID int64, request *v1.UpdateSnapshotRequest) (*v1.DiskSnapshot, error) {
 	const methodName = "Disk.UpdateSnapshot"
 
+	if err := ValidateUpdateSnapshotRequest(diskID, snapshotID, request); err != nil {
+		return nil, NewError(methodName, err)
+	}
+
 	res, err := op.client.DisksUpdateSnapshot(ctx, request, v1.DisksUpdateSnapshotParams{DiskId: diskID, SnapshotId: snapshotID})
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return nil, NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return nil, newAPIErrorFromStatusCode(methodName, e)
 		}
 		return nil, NewAPIError(methodName, 0, err)
 	}
@@ -94,7 +102,7 @@ func (op *diskOp) DeleteSnapshot(ctx context.Context, diskID, snapshotID int64)
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return newAPIErrorFromStatusCode(methodName, e)
 		}
 		return NewAPIError(methodName, 0, err)
 	}
@@ -108,7 +116,7 @@ func (op *diskOp) RestoreFromSnapshot(ctx context.Context, diskID, snapshotID in
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return newAPIErrorFromStatusCode(methodName, e)
 		}
 		return NewAPIError(methodName, 0, err)
 	}
@@ -118,11 +126,15 @@ func (op *diskOp) RestoreFromSnapshot(ctx context.Context, diskID, snapshotID in
 func (op *diskOp) Expand(ctx context.Context, diskID int64, request *v1.ExpandDiskRequest) error {
 	const methodName = "Disk.Expand"
 
+	if err := ValidateExpandDiskRequest(diskID, request); err != nil {
+		return NewError(methodName, err)
+	}
+
 	err := op.client.DisksExpand(ctx, request, v1.DisksExpandParams{ID: diskID})
 	if err != nil {
 		var e *v1.ErrorStatusCode
 		if errors.As(err, &e) {
-			return NewAPIError(methodName, e.StatusCode, errors.New(e.Response.ErrorMsg.Value))
+			return newAPIErrorFromStatusCode(methodName, e)
 		}
 		return NewAPIError(methodName, 0, err)
 	}
@@ -0,0 +1,233 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+)
+
+const (
+	DefaultDiskWaitInterval = 5 * time.Second
+	DefaultDiskWaitTimeout  = 20 * time.Minute
+	// DefaultDiskStartTimeout is the time given to a disk to leave the available state after an operation is accepted
+	DefaultDiskStartTimeout = time.Minute
+)
+
+// DiskStateError is returned when a disk is not in a state which allows the operation.
+// Failures to read the state of the disk are returned as they are, not as DiskStateError.
+type DiskStateError struct {
+	Operation string
+	DiskID    int64
+	// Availability is the availability of the disk, empty when unknown
+	Availability types.Availability
+	// Attached reports whether the disk is connected to a server
+	Attached bool
+	Reason   string
+}
+
+func (e *DiskStateError) Error() string {
+	return fmt.Sprintf("%s refused for disk %d: %s", e.Operation, e.DiskID, e.Reason)
+}
+
+// DiskStateReader reads the current state of a disk.
+type DiskStateReader interface {
+	ReadDisk(ctx context.Context, diskID int64) (*v1.Disk, error)
+}
+
+var _ DiskStateReader = (*snapshotDiskStateReader)(nil)
+
+type snapshotDiskStateReader struct {
+	disk DiskAPI
+}
+
+// NewSnapshotDiskStateReader returns a DiskStateReader which reads the disk embedded in
+// the most recent snapshot of the disk. It cannot read disks without snapshots, and only sees
+// the state of the disk as recorded in that snapshot. Prefer a reader of the disk itself such
+// as iaas.Integration.
+func NewSnapshotDiskStateReader(disk DiskAPI) DiskStateReader {
+	return &snapshotDiskStateReader{disk: disk}
+}
+
+func (r *snapshotDiskStateReader) ReadDisk(ctx context.Context, diskID int64) (*v1.Disk, error) {
+	snapshots, err := r.disk.ListSnapshots(ctx, diskID)
+	if err != nil {
+		return nil, err
+	}
+	var latest *v1.DiskSnapshot
+	for i := range snapshots.DiskSnapshots {
+		if latest == nil || snapshots.DiskSnapshots[i].CreatedAt.After(latest.CreatedAt) {
+			latest = &snapshots.DiskSnapshots[i]
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("state of disk %d is unknown: the disk has no snapshot", diskID)
+	}
+	return &latest.Disk, nil
+}
+
+// AttachedServerController stops the server a disk is attached to, so that the disk can be operated on.
+type AttachedServerController interface {
+	// StopAttachedServer stops the server the disk is attached to and returns a function which starts it again.
+	// The function may be returned with an error when stopping failed half way, to bring the server back.
+	StopAttachedServer(ctx context.Context, disk *v1.Disk) (restart func(ctx context.Context) error, err error)
+}
+
+// ErrNoDiskStateReader is returned by guarded operations when no DiskGuardOptions.StateReader is set.
+var ErrNoDiskStateReader = errors.New("the disk state cannot be checked without a StateReader")
+
+// DiskGuardOptions configures NewGuardedDiskOp.
+type DiskGuardOptions struct {
+	// StateReader reads the disk state. It is required: guarded operations fail with ErrNoDiskStateReader
+	// when nil, as the disks recorded in snapshots may be stale. It should follow the disk itself, such
+	// as iaas.Integration, see NewSnapshotDiskStateReader.
+	StateReader DiskStateReader
+	// ServerController, when set, is used to stop the server of an attached disk instead of refusing the operation.
+	// The server is started again once the disk has gone through the operation and is available again.
+	ServerController AttachedServerController
+	// WaitInterval is the polling interval while waiting for the disk before restarting the server.
+	// DefaultDiskWaitInterval is used when zero.
+	WaitInterval time.Duration
+	// WaitStartTimeout is the maximum time to wait for the disk to leave the available state once
+	// the operation is accepted. DefaultDiskStartTimeout is used when zero.
+	WaitStartTimeout time.Duration
+	// WaitTimeout is the maximum time to wait for the disk before restarting the server.
+	// DefaultDiskWaitTimeout is used when zero.
+	WaitTimeout time.Duration
+}
+
+type forceKey struct{}
+
+// WithForce returns a context which makes guarded operations skip their pre-conditions.
+func WithForce(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceKey{}, true)
+}
+
+func isForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(forceKey{}).(bool)
+	return forced
+}
+
+// IsDiskAttached reports whether the disk is connected to a server, that is whether its
+// DiskConnectionOrder is not zero. model.Disk.Attached agrees with it.
+func IsDiskAttached(disk *v1.Disk) bool {
+	return DiskConnectionOrder(disk) != 0
+}
+
+var _ DiskAPI = (*guardedDiskOp)(nil)
+
+type guardedDiskOp struct {
+	DiskAPI
+	opts DiskGuardOptions
+}
+
+// NewGuardedDiskOp returns a DiskAPI whose RestoreFromSnapshot and Expand refuse with *DiskStateError
+// unless the disk is available and not attached to a server. Use WithForce to skip the checks.
+// The operations fail with ErrNoDiskStateReader unless opts has a StateReader.
+func NewGuardedDiskOp(api DiskAPI, opts *DiskGuardOptions) DiskAPI {
+	op := &guardedDiskOp{DiskAPI: api}
+	if opts != nil {
+		op.opts = *opts
+	}
+	return op
+}
+
+func (op *guardedDiskOp) RestoreFromSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	return op.guard(ctx, "Disk.RestoreFromSnapshot", diskID, func() error {
+		return op.DiskAPI.RestoreFromSnapshot(ctx, diskID, snapshotID)
+	}, nil)
+}
+
+func (op *guardedDiskOp) Expand(ctx context.Context, diskID int64, request *v1.ExpandDiskRequest) error {
+	return op.guard(ctx, "Disk.Expand", diskID, func() error {
+		return op.DiskAPI.Expand(ctx, diskID, request)
+	}, func(disk *v1.Disk) bool {
+		return request != nil && disk.SizeMB >= request.ExpanedSizeMB
+	})
+}
+
+// guard calls fn when the disk allows it. applied reports whether the operation is visible on the disk,
+// see DiskOperationWaiter.WaitForOperation.
+func (op *guardedDiskOp) guard(ctx context.Context, methodName string, diskID int64, fn func() error, applied func(disk *v1.Disk) bool) error {
+	if isForced(ctx) {
+		return fn()
+	}
+	if op.opts.StateReader == nil {
+		return NewError(methodName, fmt.Errorf("%w, use WithForce to skip the checks", ErrNoDiskStateReader))
+	}
+
+	disk, err := op.opts.StateReader.ReadDisk(ctx, diskID)
+	if err != nil {
+		return NewError(methodName, err)
+	}
+	if !types.Availability(disk.Availability).IsReady() {
+		return &DiskStateError{
+			Operation:    methodName,
+			DiskID:       diskID,
+			Availability: types.Availability(disk.Availability),
+			Attached:     IsDiskAttached(disk),
+			Reason:       fmt.Sprintf("disk is not available: %s", disk.Availability),
+		}
+	}
+
+	var restart func(context.Context) error
+	if IsDiskAttached(disk) {
+		if op.opts.ServerController == nil {
+			return &DiskStateError{
+				Operation:    methodName,
+				DiskID:       diskID,
+				Availability: types.Availability(disk.Availability),
+				Attached:     true,
+				Reason:       "disk is attached to a server",
+			}
+		}
+		restart, err = op.opts.ServerController.StopAttachedServer(ctx, disk)
+		if err != nil {
+			err = NewError(methodName, fmt.Errorf("stopping the server of disk %d: %w", diskID, err))
+			if restart != nil {
+				if restartErr := restart(context.WithoutCancel(ctx)); restartErr != nil {
+					err = errors.Join(err, NewError(methodName, fmt.Errorf("restarting the server of disk %d: %w", diskID, restartErr)))
+				}
+			}
+			return err
+		}
+	}
+
+	err = fn()
+	if restart == nil {
+		return err
+	}
+
+	// start the server again even when the operation failed
+	ctx = context.WithoutCancel(ctx)
+	if err == nil {
+		waiter := &DiskOperationWaiter{
+			Reader:       op.opts.StateReader,
+			Interval:     op.opts.WaitInterval,
+			StartTimeout: op.opts.WaitStartTimeout,
+			Timeout:      op.opts.WaitTimeout,
+		}
+		_, err = waiter.WaitForOperation(ctx, diskID, applied)
+	}
+	if restartErr := restart(ctx); restartErr != nil {
+		err = errors.Join(err, NewError(methodName, fmt.Errorf("restarting the server of disk %d: %w", diskID, restartErr)))
+	}
+	return err
+}
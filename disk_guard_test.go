@@ -0,0 +1,159 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+	"github.com/sacloud/saclient-go"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeServerController struct {
+	stopped, started int
+}
+
+func (c *fakeServerController) StopAttachedServer(context.Context, *v1.Disk) (func(context.Context) error, error) {
+	c.stopped++
+	return func(context.Context) error {
+		c.started++
+		return nil
+	}, nil
+}
+
+func TestGuardedDiskOp(t *testing.T) {
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	detached := store.AddDisk(v1.Disk{Availability: "available", ConnectionOrder: v1.NilInt64{Null: true}, SizeMB: 20480})
+	attached := store.AddDisk(v1.Disk{Availability: "available", ConnectionOrder: v1.NewNilInt64(1), SizeMB: 20480})
+	migrating := store.AddDisk(v1.Disk{Availability: "migrating", ConnectionOrder: v1.NilInt64{Null: true}, SizeMB: 20480})
+	noSnapshot := store.AddDisk(v1.Disk{Availability: "available", ConnectionOrder: v1.NilInt64{Null: true}, SizeMB: 20480})
+
+	snapshots := make(map[int64]int64)
+	for _, disk := range []v1.Disk{detached, attached, migrating} {
+		snapshots[disk.ID] = store.AddSnapshot(v1.DiskSnapshot{
+			SnapshotState:            "available",
+			Disk:                     disk,
+			DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+		}).ID
+	}
+
+	op := dedicatedstorage.NewGuardedDiskOp(fake.NewDiskOp(store), &dedicatedstorage.DiskGuardOptions{
+		StateReader: fake.NewDiskStateReader(store),
+	})
+	ctx := t.Context()
+
+	t.Run("allowed", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(op.RestoreFromSnapshot(ctx, detached.ID, snapshots[detached.ID]))
+		assert.NoError(op.Expand(ctx, detached.ID, &v1.ExpandDiskRequest{ExpanedSizeMB: 40960}))
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		for name, diskID := range map[string]int64{"attached": attached.ID, "migrating": migrating.ID} {
+			t.Run(name, func(t *testing.T) {
+				assert := require.New(t)
+				err := op.Expand(ctx, diskID, &v1.ExpandDiskRequest{ExpanedSizeMB: 40960})
+				var stateErr *dedicatedstorage.DiskStateError
+				assert.True(errors.As(err, &stateErr))
+				assert.Equal(diskID, stateErr.DiskID)
+				assert.Equal("Disk.Expand", stateErr.Operation)
+			})
+		}
+	})
+
+	t.Run("state not readable", func(t *testing.T) {
+		assert := require.New(t)
+		err := op.Expand(ctx, noSnapshot.ID+1000, &v1.ExpandDiskRequest{ExpanedSizeMB: 40960})
+		var stateErr *dedicatedstorage.DiskStateError
+		assert.False(errors.As(err, &stateErr))
+		assert.True(saclient.IsNotFoundError(err))
+	})
+
+	t.Run("no state reader", func(t *testing.T) {
+		assert := require.New(t)
+		// the disks recorded in snapshots may be stale, the guard does not fall back to them
+		op := dedicatedstorage.NewGuardedDiskOp(fake.NewDiskOp(store), nil)
+		err := op.Expand(ctx, noSnapshot.ID, &v1.ExpandDiskRequest{ExpanedSizeMB: 40960})
+		assert.True(errors.Is(err, dedicatedstorage.ErrNoDiskStateReader))
+		err = op.RestoreFromSnapshot(ctx, detached.ID, snapshots[detached.ID])
+		assert.True(errors.Is(err, dedicatedstorage.ErrNoDiskStateReader))
+		assert.NoError(op.RestoreFromSnapshot(dedicatedstorage.WithForce(ctx), detached.ID, snapshots[detached.ID]))
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(op.RestoreFromSnapshot(dedicatedstorage.WithForce(ctx), attached.ID, snapshots[attached.ID]))
+	})
+
+	t.Run("server controller", func(t *testing.T) {
+		assert := require.New(t)
+		controller := &fakeServerController{}
+		reader := &recordingReader{DiskStateReader: fake.NewDiskStateReader(store), controller: controller}
+		op := dedicatedstorage.NewGuardedDiskOp(fake.NewDiskOp(store), &dedicatedstorage.DiskGuardOptions{
+			StateReader:      reader,
+			ServerController: controller,
+			WaitInterval:     time.Millisecond,
+		})
+		// the disk is still available right after the restore is accepted
+		store.OperationStates = []types.Availability{types.AvailabilityAvailable, types.AvailabilityMigrating, types.AvailabilityMigrating}
+		defer func() { store.OperationStates = nil }()
+
+		assert.NoError(op.RestoreFromSnapshot(ctx, attached.ID, snapshots[attached.ID]))
+		assert.Equal(1, controller.stopped)
+		assert.Equal(1, controller.started)
+		assert.Equal([]string{"available", "available", "migrating", "migrating", "available"}, reader.seen)
+	})
+
+	t.Run("operation not observed", func(t *testing.T) {
+		assert := require.New(t)
+		controller := &fakeServerController{}
+		op := dedicatedstorage.NewGuardedDiskOp(fake.NewDiskOp(store), &dedicatedstorage.DiskGuardOptions{
+			StateReader:      fake.NewDiskStateReader(store),
+			ServerController: controller,
+			WaitInterval:     time.Millisecond,
+			WaitStartTimeout: 10 * time.Millisecond,
+		})
+
+		err := op.RestoreFromSnapshot(ctx, attached.ID, snapshots[attached.ID])
+		assert.True(errors.Is(err, dedicatedstorage.ErrDiskOperationNotObserved))
+		assert.Equal(1, controller.started)
+
+		// the expanded size tells that the operation completed between two polls
+		assert.NoError(op.Expand(ctx, attached.ID, &v1.ExpandDiskRequest{ExpanedSizeMB: 40960}))
+	})
+}
+
+// recordingReader records the availability of every read made while the server is stopped
+type recordingReader struct {
+	dedicatedstorage.DiskStateReader
+	controller *fakeServerController
+	seen       []string
+}
+
+func (r *recordingReader) ReadDisk(ctx context.Context, diskID int64) (*v1.Disk, error) {
+	disk, err := r.DiskStateReader.ReadDisk(ctx, diskID)
+	if err == nil && r.controller.started == 0 {
+		r.seen = append(r.seen, disk.Availability)
+	}
+	return disk, err
+}
@@ -0,0 +1,268 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+)
+
+// PlannedOperation is a mutating call which a dry-run op would have sent.
+type PlannedOperation struct {
+	// Method is the method name such as "Disk.CreateSnapshot"
+	Method     string `json:"method"`
+	ContractID int64  `json:"contract_id,omitempty"`
+	DiskID     int64  `json:"disk_id,omitempty"`
+	SnapshotID int64  `json:"snapshot_id,omitempty"`
+	// Request is the request body which would have been sent, if any
+	Request any `json:"request,omitempty"`
+	// Current is the current state of the target resource resolved via read calls, if any
+	Current any `json:"current,omitempty"`
+}
+
+// DryRunPlan records the operations planned by dry-run ops. It is safe for concurrent use.
+type DryRunPlan struct {
+	mu         sync.Mutex
+	operations []PlannedOperation
+}
+
+// Operations returns the planned operations in the order they were recorded.
+func (p *DryRunPlan) Operations() []PlannedOperation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PlannedOperation(nil), p.operations...)
+}
+
+// Reset discards all recorded operations.
+func (p *DryRunPlan) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.operations = nil
+}
+
+func (p *DryRunPlan) record(op PlannedOperation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.operations = append(p.operations, op)
+}
+
+var _ ContractAPI = (*dryRunContractOp)(nil)
+
+type dryRunContractOp struct {
+	ContractAPI
+	plan *DryRunPlan
+}
+
+// NewDryRunContractOp returns a ContractAPI which passes read calls through to api and
+// records mutating calls into plan instead of sending them.
+//
+// Mutating calls validate their inputs and resolve the referenced resources via read calls,
+// then return the resource as it would look after the call. IDs assigned by the API are left zero.
+func NewDryRunContractOp(api ContractAPI, plan *DryRunPlan) ContractAPI {
+	return &dryRunContractOp{ContractAPI: api, plan: plan}
+}
+
+func (op *dryRunContractOp) Create(ctx context.Context, req v1.CreateDedicatedStorageContractRequest) (*v1.DedicatedStorageContract, error) {
+	const methodName = "Contract.Create"
+
+	if err := ValidateCreateContractRequest(&req); err != nil {
+		return nil, NewError(methodName, err)
+	}
+	contract := req.DedicatedStorageContract
+	plan, err := op.ContractAPI.ReadPlan(ctx, contract.Plan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	op.plan.record(PlannedOperation{Method: methodName, Request: req, Current: plan})
+	return &v1.DedicatedStorageContract{
+		Name:        contract.Name,
+		Description: contract.Description,
+		Plan:        v1.Plan(*plan),
+		Icon:        contract.Icon,
+		Tags:        contract.Tags,
+	}, nil
+}
+
+func (op *dryRunContractOp) Update(ctx context.Context, id int64, req v1.UpdateDedicatedStorageContractRequest) (*v1.DedicatedStorageContract, error) {
+	const methodName = "Contract.Update"
+
+	if err := ValidateUpdateContractRequest(id, &req); err != nil {
+		return nil, NewError(methodName, err)
+	}
+	current, err := op.ContractAPI.Read(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	op.plan.record(PlannedOperation{Method: methodName, ContractID: id, Request: req, Current: current})
+	updated := *current
+	updated.Name = req.DedicatedStorageContract.Name
+	updated.Description = req.DedicatedStorageContract.Description
+	updated.Tags = req.DedicatedStorageContract.Tags
+	// an unset icon is kept as it is, like the API does
+	if req.DedicatedStorageContract.Icon.Set {
+		updated.Icon = req.DedicatedStorageContract.Icon
+	}
+	return &updated, nil
+}
+
+func (op *dryRunContractOp) Delete(ctx context.Context, id int64) error {
+	const methodName = "Contract.Delete"
+
+	if id <= 0 {
+		return NewError(methodName, errors.New("contract ID is required"))
+	}
+	current, err := op.ContractAPI.Read(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	op.plan.record(PlannedOperation{Method: methodName, ContractID: id, Current: current})
+	return nil
+}
+
+var _ DiskAPI = (*dryRunDiskOp)(nil)
+
+type dryRunDiskOp struct {
+	DiskAPI
+	contract ContractAPI
+	plan     *DryRunPlan
+}
+
+// NewDryRunDiskOp returns a DiskAPI which passes read calls through to api and
+// records mutating calls into plan instead of sending them.
+//
+// contract is used to resolve the contract referenced from CreateSnapshot.
+func NewDryRunDiskOp(api DiskAPI, contract ContractAPI, plan *DryRunPlan) DiskAPI {
+	return &dryRunDiskOp{DiskAPI: api, contract: contract, plan: plan}
+}
+
+func (op *dryRunDiskOp) CreateSnapshot(ctx context.Context, diskID int64, request *v1.CreateSnapshotRequest) (*v1.DiskSnapshot, error) {
+	const methodName = "Disk.CreateSnapshot"
+
+	if err := ValidateCreateSnapshotRequest(diskID, request); err != nil {
+		return nil, NewError(methodName, err)
+	}
+	contractID := request.DiskSnapshot.DedicatedStorageContract.ID
+	if _, err := op.contract.Read(ctx, contractID); err != nil {
+		return nil, err
+	}
+	snapshots, err := op.DiskAPI.ListSnapshots(ctx, diskID)
+	if err != nil {
+		return nil, err
+	}
+
+	op.plan.record(PlannedOperation{Method: methodName, ContractID: contractID, DiskID: diskID, Request: request})
+	snapshot := &v1.DiskSnapshot{
+		Name:                     request.DiskSnapshot.Name,
+		Description:              request.DiskSnapshot.Description,
+		DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contractID},
+	}
+	if len(snapshots.DiskSnapshots) > 0 {
+		snapshot.Disk = snapshots.DiskSnapshots[0].Disk
+	} else {
+		snapshot.Disk.ID = diskID
+	}
+	return snapshot, nil
+}
+
+func (op *dryRunDiskOp) UpdateSnapshot(ctx context.Context, diskID, snapshotID int64, request *v1.UpdateSnapshotRequest) (*v1.DiskSnapshot, error) {
+	const methodName = "Disk.UpdateSnapshot"
+
+	if err := ValidateUpdateSnapshotRequest(diskID, snapshotID, request); err != nil {
+		return nil, NewError(methodName, err)
+	}
+	current, err := op.resolveSnapshot(ctx, methodName, diskID, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	op.plan.record(PlannedOperation{Method: methodName, DiskID: diskID, SnapshotID: snapshotID, Request: request, Current: current})
+	updated := *current
+	updated.Name = request.DiskSnapshot.Name
+	updated.Description = request.DiskSnapshot.Description
+	return &updated, nil
+}
+
+func (op *dryRunDiskOp) DeleteSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	const methodName = "Disk.DeleteSnapshot"
+
+	current, err := op.resolveSnapshot(ctx, methodName, diskID, snapshotID)
+	if err != nil {
+		return err
+	}
+
+	op.plan.record(PlannedOperation{Method: methodName, DiskID: diskID, SnapshotID: snapshotID, Current: current})
+	return nil
+}
+
+func (op *dryRunDiskOp) RestoreFromSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	const methodName = "Disk.RestoreFromSnapshot"
+
+	current, err := op.resolveSnapshot(ctx, methodName, diskID, snapshotID)
+	if err != nil {
+		return err
+	}
+
+	op.plan.record(PlannedOperation{Method: methodName, DiskID: diskID, SnapshotID: snapshotID, Current: current})
+	return nil
+}
+
+func (op *dryRunDiskOp) Expand(ctx context.Context, diskID int64, request *v1.ExpandDiskRequest) error {
+	const methodName = "Disk.Expand"
+
+	if err := ValidateExpandDiskRequest(diskID, request); err != nil {
+		return NewError(methodName, err)
+	}
+	snapshots, err := op.DiskAPI.ListSnapshots(ctx, diskID)
+	if err != nil {
+		return err
+	}
+
+	planned := PlannedOperation{Method: methodName, DiskID: diskID, Request: request}
+	if len(snapshots.DiskSnapshots) > 0 {
+		disk := snapshots.DiskSnapshots[0].Disk
+		if request.ExpanedSizeMB <= disk.SizeMB {
+			return NewError(methodName, fmt.Errorf("expanded size %dMB must be larger than current size %dMB", request.ExpanedSizeMB, disk.SizeMB))
+		}
+		planned.Current = disk
+	}
+	op.plan.record(planned)
+	return nil
+}
+
+func (op *dryRunDiskOp) resolveSnapshot(ctx context.Context, methodName string, diskID, snapshotID int64) (*v1.DiskSnapshot, error) {
+	if diskID <= 0 {
+		return nil, NewError(methodName, errors.New("disk ID is required"))
+	}
+	if snapshotID <= 0 {
+		return nil, NewError(methodName, errors.New("snapshot ID is required"))
+	}
+	snapshots, err := op.DiskAPI.ListSnapshots(ctx, diskID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range snapshots.DiskSnapshots {
+		if snapshots.DiskSnapshots[i].ID == snapshotID {
+			return &snapshots.DiskSnapshots[i], nil
+		}
+	}
+	return nil, NewError(methodName, fmt.Errorf("snapshot %d not found on disk %d", snapshotID, diskID))
+}
@@ -0,0 +1,135 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage_test
+
+import (
+	"testing"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/sacloud/saclient-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunContractOp(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+
+	store := fake.NewStore()
+	store.AddPlan(v1.DedicatedStorageContractPlan{ID: 1, Name: "plan"})
+	existing := store.AddContract(v1.DedicatedStorageContract{Name: "existing"})
+
+	plan := &dedicatedstorage.DryRunPlan{}
+	op := dedicatedstorage.NewDryRunContractOp(fake.NewContractOp(store), plan)
+
+	created, err := op.Create(ctx, v1.CreateDedicatedStorageContractRequest{
+		DedicatedStorageContract: v1.CreateDedicatedStorageContractRequestDedicatedStorageContract{
+			Plan: v1.CreateDedicatedStorageContractRequestDedicatedStorageContractPlan{ID: 1},
+			Name: "new",
+		},
+	})
+	assert.NoError(err)
+	assert.Equal("new", created.Name)
+	assert.Equal("plan", created.Plan.Name)
+
+	_, err = op.Create(ctx, v1.CreateDedicatedStorageContractRequest{
+		DedicatedStorageContract: v1.CreateDedicatedStorageContractRequestDedicatedStorageContract{
+			Plan: v1.CreateDedicatedStorageContractRequestDedicatedStorageContractPlan{ID: 2},
+			Name: "unknown-plan",
+		},
+	})
+	assert.True(saclient.IsNotFoundError(err))
+
+	updated, err := op.Update(ctx, existing.ID, v1.UpdateDedicatedStorageContractRequest{
+		DedicatedStorageContract: v1.UpdateDedicatedStorageContractRequestDedicatedStorageContract{Name: "renamed"},
+	})
+	assert.NoError(err)
+	assert.Equal(existing.ID, updated.ID)
+	assert.Equal("renamed", updated.Name)
+
+	assert.NoError(op.Delete(ctx, existing.ID))
+	assert.Error(op.Delete(ctx, 0))
+
+	ops := plan.Operations()
+	assert.Len(ops, 3)
+	assert.Equal("Contract.Create", ops[0].Method)
+	assert.Equal("Contract.Update", ops[1].Method)
+	assert.Equal("Contract.Delete", ops[2].Method)
+	assert.Equal(existing.ID, ops[2].ContractID)
+
+	// nothing was actually changed
+	stored, ok := store.Contract(existing.ID)
+	assert.True(ok)
+	assert.Equal("existing", stored.Name)
+	list, err := op.List(ctx)
+	assert.NoError(err)
+	assert.Len(list.DedicatedStorageContracts, 1)
+}
+
+func TestDryRunDiskOp(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{Name: "contract"})
+	disk := store.AddDisk(v1.Disk{Name: "disk", SizeMB: 20480})
+	snapshot := store.AddSnapshot(v1.DiskSnapshot{
+		Name:                     "snapshot",
+		Disk:                     disk,
+		DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+	})
+
+	plan := &dedicatedstorage.DryRunPlan{}
+	op := dedicatedstorage.NewDryRunDiskOp(fake.NewDiskOp(store), fake.NewContractOp(store), plan)
+
+	created, err := op.CreateSnapshot(ctx, disk.ID, &v1.CreateSnapshotRequest{
+		DiskSnapshot: v1.CreateSnapshotRequestDiskSnapshot{
+			DedicatedStorageContract: v1.CreateSnapshotRequestDiskSnapshotDedicatedStorageContract{ID: contract.ID},
+			Name:                     "new",
+		},
+	})
+	assert.NoError(err)
+	assert.Equal(disk.ID, created.Disk.ID)
+
+	_, err = op.UpdateSnapshot(ctx, disk.ID, snapshot.ID, &v1.UpdateSnapshotRequest{
+		DiskSnapshot: v1.UpdateSnapshotRequestDiskSnapshot{Name: "renamed"},
+	})
+	assert.NoError(err)
+	assert.NoError(op.RestoreFromSnapshot(ctx, disk.ID, snapshot.ID))
+	assert.NoError(op.DeleteSnapshot(ctx, disk.ID, snapshot.ID))
+	assert.Error(op.DeleteSnapshot(ctx, disk.ID, snapshot.ID+1))
+	assert.NoError(op.Expand(ctx, disk.ID, &v1.ExpandDiskRequest{ExpanedSizeMB: 40960}))
+	assert.Error(op.Expand(ctx, disk.ID, &v1.ExpandDiskRequest{ExpanedSizeMB: 10240}))
+
+	var methods []string
+	for _, v := range plan.Operations() {
+		methods = append(methods, v.Method)
+	}
+	assert.Equal([]string{
+		"Disk.CreateSnapshot",
+		"Disk.UpdateSnapshot",
+		"Disk.RestoreFromSnapshot",
+		"Disk.DeleteSnapshot",
+		"Disk.Expand",
+	}, methods)
+
+	assert.Len(store.Snapshots(), 1)
+	stored, _ := store.Disk(disk.ID)
+	assert.Equal(int64(20480), stored.SizeMB)
+	for _, call := range store.Calls() {
+		assert.NotContains([]string{"Disk.CreateSnapshot", "Disk.UpdateSnapshot", "Disk.DeleteSnapshot", "Disk.RestoreFromSnapshot", "Disk.Expand"}, call)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+)
+
+// ErrAmbiguousMatch is returned when more than one resource matches an ensure call.
+var ErrAmbiguousMatch = errors.New("more than one resource matches")
+
+// EnsureAction is what an ensure call did to converge.
+type EnsureAction string
+
+const (
+	EnsureCreated   EnsureAction = "created"
+	EnsureUpdated   EnsureAction = "updated"
+	EnsureUnchanged EnsureAction = "unchanged"
+)
+
+// ContractSpec is the desired state of a contract.
+type ContractSpec struct {
+	ContractCreateParams
+	// MatchTag, when set, finds the contract by this tag instead of by name.
+	// It must be one of Tags so that the created contract is found again.
+	MatchTag string
+}
+
+type EnsureAPI interface {
+	EnsureContract(ctx context.Context, spec *ContractSpec) (*v1.DedicatedStorageContract, EnsureAction, error)
+	EnsureSnapshot(ctx context.Context, diskID int64, params *SnapshotCreateParams, window time.Duration) (*v1.DiskSnapshot, EnsureAction, error)
+}
+
+var _ EnsureAPI = (*ensureOp)(nil)
+
+type ensureOp struct {
+	contract ContractAPI
+	disk     DiskAPI
+	now      func() time.Time
+}
+
+// NewEnsureOp returns an EnsureAPI.
+func NewEnsureOp(contract ContractAPI, disk DiskAPI) EnsureAPI {
+	return &ensureOp{contract: contract, disk: disk, now: time.Now}
+}
+
+// EnsureContract finds the contract by name, or by spec.MatchTag when set, and creates it when missing
+// or updates it when its name, description, tags or icon differ from the spec.
+//
+// The plan of an existing contract cannot be changed: a contract with another plan is an error.
+func (op *ensureOp) EnsureContract(ctx context.Context, spec *ContractSpec) (*v1.DedicatedStorageContract, EnsureAction, error) {
+	const methodName = "Ensure.Contract"
+
+	if spec.MatchTag != "" && !slices.Contains(spec.Tags, spec.MatchTag) {
+		return nil, "", NewError(methodName, fmt.Errorf("match tag %q is not in the tags of the spec", spec.MatchTag))
+	}
+	request := spec.Request()
+	if err := ValidateCreateContractRequest(&request); err != nil {
+		return nil, "", NewError(methodName, err)
+	}
+
+	contracts, err := op.contract.List(ctx)
+	if err != nil {
+		return nil, "", NewError(methodName, err)
+	}
+	var matched []*v1.DedicatedStorageContract
+	for i := range contracts.DedicatedStorageContracts {
+		contract := &contracts.DedicatedStorageContracts[i]
+		if spec.MatchTag != "" && slices.Contains(contract.Tags, spec.MatchTag) ||
+			spec.MatchTag == "" && contract.Name == spec.Name {
+			matched = append(matched, contract)
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		created, err := op.contract.Create(ctx, request)
+		if err != nil {
+			return nil, "", NewError(methodName, err)
+		}
+		return created, EnsureCreated, nil
+	case 1:
+	default:
+		return nil, "", NewError(methodName, fmt.Errorf("%w: %d contracts", ErrAmbiguousMatch, len(matched)))
+	}
+
+	current := matched[0]
+	if current.Plan.ID != spec.PlanID {
+		return nil, "", NewError(methodName, fmt.Errorf("contract %d has plan %d, the spec requires plan %d", current.ID, current.Plan.ID, spec.PlanID))
+	}
+	if current.Name == spec.Name && current.Description == spec.Description &&
+		sameTags(current.Tags, spec.Tags) && ContractIconID(current) == spec.IconID {
+		return current, EnsureUnchanged, nil
+	}
+
+	// a zero IconID removes the icon, see ContractUpdateParams
+	update := &ContractUpdateParams{Name: spec.Name, Description: spec.Description, Tags: spec.Tags, IconID: spec.IconID}
+	updated, err := op.contract.Update(ctx, current.ID, update.Request())
+	if err != nil {
+		return nil, "", NewError(methodName, err)
+	}
+	return updated, EnsureUpdated, nil
+}
+
+// EnsureSnapshot returns the snapshot of the disk named params.Name and created within window,
+// or creates one when there is none. Failed snapshots are ignored. Any age matches when window is zero.
+func (op *ensureOp) EnsureSnapshot(ctx context.Context, diskID int64, params *SnapshotCreateParams, window time.Duration) (*v1.DiskSnapshot, EnsureAction, error) {
+	const methodName = "Ensure.Snapshot"
+
+	request, err := params.Request()
+	if err != nil {
+		return nil, "", NewError(methodName, err)
+	}
+	if err := ValidateCreateSnapshotRequest(diskID, request); err != nil {
+		return nil, "", NewError(methodName, err)
+	}
+
+	snapshots, err := op.disk.ListSnapshots(ctx, diskID)
+	if err != nil {
+		return nil, "", NewError(methodName, err)
+	}
+	since := op.now().Add(-window)
+	var latest *v1.DiskSnapshot
+	for i := range snapshots.DiskSnapshots {
+		snapshot := &snapshots.DiskSnapshots[i]
+		if snapshot.Name != params.Name || types.SnapshotState(snapshot.SnapshotState).IsFailed() {
+			continue
+		}
+		if window > 0 && snapshot.CreatedAt.Before(since) {
+			continue
+		}
+		if latest == nil || snapshot.CreatedAt.After(latest.CreatedAt) {
+			latest = snapshot
+		}
+	}
+	if latest != nil {
+		return latest, EnsureUnchanged, nil
+	}
+
+	created, err := op.disk.CreateSnapshot(ctx, diskID, request)
+	if err != nil {
+		return nil, "", NewError(methodName, err)
+	}
+	return created, EnsureCreated, nil
+}
+
+func sameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
@@ -0,0 +1,129 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureOp_EnsureContract(t *testing.T) {
+	store := fake.NewStore()
+	store.AddPlan(v1.DedicatedStorageContractPlan{ID: 1})
+	op := dedicatedstorage.NewEnsureOp(fake.NewContractOp(store), fake.NewDiskOp(store))
+	ctx := t.Context()
+
+	spec := &dedicatedstorage.ContractSpec{
+		ContractCreateParams: dedicatedstorage.ContractCreateParams{PlanID: 1, Name: "storage", Tags: []string{"a", "b"}},
+	}
+
+	t.Run("create then unchanged", func(t *testing.T) {
+		assert := require.New(t)
+		created, action, err := op.EnsureContract(ctx, spec)
+		assert.NoError(err)
+		assert.Equal(dedicatedstorage.EnsureCreated, action)
+
+		spec.Tags = []string{"b", "a"}
+		again, action, err := op.EnsureContract(ctx, spec)
+		assert.NoError(err)
+		assert.Equal(dedicatedstorage.EnsureUnchanged, action)
+		assert.Equal(created.ID, again.ID)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		assert := require.New(t)
+		spec.Description = "updated"
+		updated, action, err := op.EnsureContract(ctx, spec)
+		assert.NoError(err)
+		assert.Equal(dedicatedstorage.EnsureUpdated, action)
+		assert.Equal("updated", updated.Description)
+	})
+
+	t.Run("remove icon", func(t *testing.T) {
+		assert := require.New(t)
+		withIcon := store.AddContract(v1.DedicatedStorageContract{Name: "icon", Plan: v1.Plan{ID: 1}, Icon: v1.NewOptNilIcon(v1.Icon{ID: 5})})
+		spec := &dedicatedstorage.ContractSpec{
+			ContractCreateParams: dedicatedstorage.ContractCreateParams{PlanID: 1, Name: "icon"},
+		}
+
+		updated, action, err := op.EnsureContract(ctx, spec)
+		assert.NoError(err)
+		assert.Equal(dedicatedstorage.EnsureUpdated, action)
+		assert.Zero(dedicatedstorage.ContractIconID(updated))
+
+		again, action, err := op.EnsureContract(ctx, spec)
+		assert.NoError(err)
+		assert.Equal(dedicatedstorage.EnsureUnchanged, action)
+		assert.Equal(withIcon.ID, again.ID)
+	})
+
+	t.Run("rename by tag", func(t *testing.T) {
+		assert := require.New(t)
+		renamed := *spec
+		renamed.Name = "renamed"
+		renamed.MatchTag = "a"
+		updated, action, err := op.EnsureContract(ctx, &renamed)
+		assert.NoError(err)
+		assert.Equal(dedicatedstorage.EnsureUpdated, action)
+		assert.Equal("renamed", updated.Name)
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		store.AddContract(v1.DedicatedStorageContract{Name: "other", Tags: []string{"a"}})
+		byTag := *spec
+		byTag.MatchTag = "a"
+		_, _, err := op.EnsureContract(ctx, &byTag)
+		require.True(t, errors.Is(err, dedicatedstorage.ErrAmbiguousMatch))
+	})
+}
+
+func TestEnsureOp_EnsureSnapshot(t *testing.T) {
+	assert := require.New(t)
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	disk := store.AddDisk(v1.Disk{})
+	op := dedicatedstorage.NewEnsureOp(fake.NewContractOp(store), fake.NewDiskOp(store))
+	params := &dedicatedstorage.SnapshotCreateParams{ContractID: contract.ID, Name: "daily"}
+
+	store.AddSnapshot(v1.DiskSnapshot{
+		Name:                     "daily",
+		SnapshotState:            "available",
+		CreatedAt:                time.Now().Add(-48 * time.Hour),
+		Disk:                     disk,
+		DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+	})
+
+	created, action, err := op.EnsureSnapshot(t.Context(), disk.ID, params, 24*time.Hour)
+	assert.NoError(err)
+	assert.Equal(dedicatedstorage.EnsureCreated, action)
+
+	again, action, err := op.EnsureSnapshot(t.Context(), disk.ID, params, 24*time.Hour)
+	assert.NoError(err)
+	assert.Equal(dedicatedstorage.EnsureUnchanged, action)
+	assert.Equal(created.ID, again.ID)
+
+	store.SetSnapshotState(created.ID, "failed")
+	_, action, err = op.EnsureSnapshot(t.Context(), disk.ID, params, 24*time.Hour)
+	assert.NoError(err)
+	assert.Equal(dedicatedstorage.EnsureCreated, action)
+	assert.Len(store.Snapshots(), 3)
+}
@@ -15,8 +15,10 @@
 package dedicatedstorage
 
 import (
+	"errors"
 	"strings"
 
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
 	"github.com/sacloud/saclient-go"
 )
 
@@ -48,3 +50,23 @@ func NewError(msg string, err error) *Error { return &Error{msg: msg, err: err}
 func NewAPIError(method string, code int, err error) *Error {
 	return NewError(method, saclient.NewError(code, "", err))
 }
+
+func newAPIErrorFromStatusCode(method string, e *v1.ErrorStatusCode) *Error {
+	return NewAPIError(method, e.StatusCode, &errorResponse{response: e.Response})
+}
+
+// errorResponse keeps the error body returned from the API while reporting only its message.
+type errorResponse struct {
+	response v1.Error
+}
+
+func (e *errorResponse) Error() string { return e.response.ErrorMsg.Value }
+
+// ErrorResponse returns the error body returned from the API, such as its serial and error code, if err wraps one.
+func ErrorResponse(err error) (*v1.Error, bool) {
+	var e *errorResponse
+	if errors.As(err, &e) {
+		return &e.response, true
+	}
+	return nil, false
+}
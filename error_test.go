@@ -18,6 +18,7 @@ import (
 	"errors"
 	"testing"
 
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
 	"github.com/sacloud/saclient-go"
 	"github.com/stretchr/testify/require"
 )
@@ -79,3 +80,25 @@ func TestNewAPIError(t *testing.T) {
 	assert.Equal("msg", err2.msg)
 	assert.False(saclient.IsNotFoundError(err2))
 }
+
+func TestErrorResponse(t *testing.T) {
+	assert := require.New(t)
+
+	err := newAPIErrorFromStatusCode("msg", &v1.ErrorStatusCode{
+		StatusCode: 409,
+		Response: v1.Error{
+			Serial:    v1.NewOptString("serial"),
+			ErrorCode: v1.NewOptString("still_used"),
+			ErrorMsg:  v1.NewOptString("error message"),
+		},
+	})
+	assert.Equal("dedicated-storage: msg: API Error 409: error message", err.Error())
+
+	res, ok := ErrorResponse(err)
+	assert.True(ok)
+	assert.Equal("serial", res.Serial.Value)
+	assert.Equal("still_used", res.ErrorCode.Value)
+
+	_, ok = ErrorResponse(NewAPIError("msg", 0, errors.New("base error")))
+	assert.False(ok)
+}
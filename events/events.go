@@ -0,0 +1,148 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events emits CloudEvents v1.0 for the results of ContractAPI/DiskAPI operations.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SpecVersion is the CloudEvents specification version of the events.
+const SpecVersion = "1.0"
+
+// DefaultSource is the source of events when Emitter.Source is empty.
+const DefaultSource = "github.com/sacloud/dedicated-storage-api-go"
+
+// Event types. They are stable and never change once released.
+const (
+	TypeContractCreated     = "jp.sakura.dedicatedstorage.contract.created"
+	TypeContractUpdated     = "jp.sakura.dedicatedstorage.contract.updated"
+	TypeContractDeleted     = "jp.sakura.dedicatedstorage.contract.deleted"
+	TypeSnapshotCreated     = "jp.sakura.dedicatedstorage.snapshot.created"
+	TypeSnapshotUpdated     = "jp.sakura.dedicatedstorage.snapshot.updated"
+	TypeSnapshotDeleted     = "jp.sakura.dedicatedstorage.snapshot.deleted"
+	TypeDiskRestoreAccepted = "jp.sakura.dedicatedstorage.disk.restore.accepted"
+	TypeDiskExpandAccepted  = "jp.sakura.dedicatedstorage.disk.expand.accepted"
+)
+
+const (
+	contentTypeJSON            = "application/json"
+	contentTypeCloudEventsJSON = "application/cloudevents+json"
+)
+
+// Event is a CloudEvents v1.0 event whose data is JSON.
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// Sender delivers events somewhere.
+type Sender interface {
+	Send(ctx context.Context, event *Event) error
+}
+
+// SenderFunc adapts a function to Sender.
+type SenderFunc func(ctx context.Context, event *Event) error
+
+func (f SenderFunc) Send(ctx context.Context, event *Event) error { return f(ctx, event) }
+
+// Emitter builds events and sends them with its sender.
+type Emitter struct {
+	Sender Sender
+	// Source is the source attribute of the events. DefaultSource is used when empty.
+	Source string
+	// OnSendError is called when the event cannot be built or the sender fails. Send errors never
+	// fail the operation emitting the event. event is never nil; when building it failed, it has
+	// the attributes built so far and no data.
+	OnSendError func(event *Event, err error)
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+}
+
+// NewEmitter returns an Emitter sending events with sender.
+func NewEmitter(sender Sender) *Emitter {
+	return &Emitter{Sender: sender}
+}
+
+// Emit builds an event with data encoded as JSON and sends it.
+func (e *Emitter) Emit(ctx context.Context, eventType, subject string, data any) error {
+	event, err := e.newEvent(eventType, subject, data)
+	if err != nil {
+		return err
+	}
+	return e.Sender.Send(ctx, event)
+}
+
+func (e *Emitter) emit(ctx context.Context, eventType, subject string, data any) {
+	event, err := e.newEvent(eventType, subject, data)
+	if err == nil {
+		err = e.Sender.Send(ctx, event)
+	}
+	if err != nil && e.OnSendError != nil {
+		e.OnSendError(event, err)
+	}
+}
+
+// newEvent returns the event along with any error building it, so that the error can be reported with it
+func (e *Emitter) newEvent(eventType, subject string, data any) (*Event, error) {
+	now := time.Now
+	if e.Now != nil {
+		now = e.Now
+	}
+	source := e.Source
+	if source == "" {
+		source = DefaultSource
+	}
+	event := &Event{
+		SpecVersion: SpecVersion,
+		Source:      source,
+		Type:        eventType,
+		Subject:     subject,
+		Time:        now().UTC(),
+	}
+	id, err := newEventID()
+	if err != nil {
+		return event, err
+	}
+	event.ID = id
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return event, fmt.Errorf("encoding data of %s: %w", eventType, err)
+		}
+		event.DataContentType = contentTypeJSON
+		event.Data = encoded
+	}
+	return event, nil
+}
+
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
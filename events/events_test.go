@@ -0,0 +1,154 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOps(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	disk := store.AddDisk(v1.Disk{SizeMB: 20 * 1024})
+
+	var sent []*Event
+	emitter := NewEmitter(SenderFunc(func(_ context.Context, event *Event) error {
+		sent = append(sent, event)
+		return nil
+	}))
+	diskOp := NewDiskOp(fake.NewDiskOp(store), emitter)
+
+	snapshot, err := diskOp.CreateSnapshot(ctx, disk.ID, &v1.CreateSnapshotRequest{
+		DiskSnapshot: v1.CreateSnapshotRequestDiskSnapshot{
+			DedicatedStorageContract: v1.CreateSnapshotRequestDiskSnapshotDedicatedStorageContract{ID: contract.ID},
+			Name:                     "snapshot",
+		},
+	})
+	assert.NoError(err)
+	assert.NoError(diskOp.RestoreFromSnapshot(ctx, disk.ID, snapshot.ID))
+	assert.Error(diskOp.Expand(ctx, disk.ID, &v1.ExpandDiskRequest{ExpanedSizeMB: 10 * 1024}))
+
+	assert.Len(sent, 2)
+	assert.Equal(TypeSnapshotCreated, sent[0].Type)
+	assert.Equal(SpecVersion, sent[0].SpecVersion)
+	assert.Equal(DefaultSource, sent[0].Source)
+	assert.NotEmpty(sent[0].ID)
+	var data map[string]any
+	assert.NoError(json.Unmarshal(sent[0].Data, &data))
+	assert.Equal("snapshot", data["name"])
+
+	assert.Equal(TypeDiskRestoreAccepted, sent[1].Type)
+	assert.Equal("disks/"+strconv.FormatInt(disk.ID, 10), sent[1].Subject)
+
+	contractOp := NewContractOp(fake.NewContractOp(store), emitter)
+	assert.NoError(fake.NewDiskOp(store).DeleteSnapshot(ctx, disk.ID, snapshot.ID))
+	assert.NoError(contractOp.Delete(ctx, contract.ID))
+	assert.Equal(TypeContractDeleted, sent[2].Type)
+}
+
+func TestEmitter_SendError(t *testing.T) {
+	assert := require.New(t)
+
+	var sendErr error
+	emitter := &Emitter{
+		Sender:      SenderFunc(func(context.Context, *Event) error { return errors.New("down") }),
+		OnSendError: func(_ *Event, err error) { sendErr = err },
+	}
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+
+	assert.NoError(NewContractOp(fake.NewContractOp(store), emitter).Delete(t.Context(), contract.ID))
+	assert.EqualError(sendErr, "down")
+
+	// events which cannot be built are reported with the attributes built so far
+	var failed *Event
+	emitter.OnSendError = func(event *Event, err error) { failed, sendErr = event, err }
+	emitter.emit(t.Context(), TypeContractDeleted, "contracts/1", make(chan int))
+	assert.ErrorContains(sendErr, "encoding data")
+	assert.NotNil(failed)
+	assert.Equal(TypeContractDeleted, failed.Type)
+	assert.Nil(failed.Data)
+}
+
+func TestHTTPSender(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var requests []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, received{header: r.Header, body: body})
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	emitter := &Emitter{
+		Source: "test",
+		Now:    func() time.Time { return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) },
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		assert := require.New(t)
+		emitter.Sender = NewHTTPSender(server.URL, ModeBinary)
+		assert.NoError(emitter.Emit(t.Context(), TypeContractDeleted, "contracts/1", &ContractDeletedData{ContractID: 1}))
+
+		req := requests[len(requests)-1]
+		assert.Equal("1.0", req.header.Get("ce-specversion"))
+		assert.Equal(TypeContractDeleted, req.header.Get("ce-type"))
+		assert.Equal("test", req.header.Get("ce-source"))
+		assert.Equal("contracts/1", req.header.Get("ce-subject"))
+		assert.Equal("2025-06-01T00:00:00Z", req.header.Get("ce-time"))
+		assert.Equal("application/json", req.header.Get("Content-Type"))
+		assert.JSONEq(`{"contract_id":1}`, string(req.body))
+	})
+
+	t.Run("structured", func(t *testing.T) {
+		assert := require.New(t)
+		emitter.Sender = NewHTTPSender(server.URL, ModeStructured)
+		assert.NoError(emitter.Emit(t.Context(), TypeContractDeleted, "contracts/1", &ContractDeletedData{ContractID: 1}))
+
+		req := requests[len(requests)-1]
+		assert.Equal("application/cloudevents+json", req.header.Get("Content-Type"))
+		var event Event
+		assert.NoError(json.Unmarshal(req.body, &event))
+		assert.Equal(TypeContractDeleted, event.Type)
+		assert.JSONEq(`{"contract_id":1}`, string(event.Data))
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer failing.Close()
+		emitter.Sender = NewHTTPSender(failing.URL, ModeBinary)
+		require.Error(t, emitter.Emit(t.Context(), TypeContractDeleted, "contracts/1", nil))
+	})
+}
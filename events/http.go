@@ -0,0 +1,112 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Mode is the content mode of the CloudEvents HTTP protocol binding.
+type Mode string
+
+const (
+	// ModeBinary sends the data as the body and the attributes as ce- headers
+	ModeBinary Mode = "binary"
+	// ModeStructured sends the whole event as a JSON body
+	ModeStructured Mode = "structured"
+)
+
+var _ Sender = (*HTTPSender)(nil)
+
+// HTTPSender posts events to an HTTP endpoint.
+type HTTPSender struct {
+	URL string
+	// Mode is ModeBinary when empty
+	Mode Mode
+	// Header is added to every request, for example for authorization
+	Header http.Header
+	// Client is http.DefaultClient when nil
+	Client *http.Client
+}
+
+// NewHTTPSender returns an HTTPSender posting to url in the mode.
+func NewHTTPSender(url string, mode Mode) *HTTPSender {
+	return &HTTPSender{URL: url, Mode: mode}
+}
+
+func (s *HTTPSender) Send(ctx context.Context, event *Event) error {
+	var (
+		body        []byte
+		contentType string
+		header      = make(http.Header)
+	)
+	switch s.Mode {
+	case ModeBinary, "":
+		body, contentType = event.Data, event.DataContentType
+		header.Set("ce-specversion", event.SpecVersion)
+		header.Set("ce-id", event.ID)
+		header.Set("ce-source", event.Source)
+		header.Set("ce-type", event.Type)
+		header.Set("ce-time", event.Time.Format(time.RFC3339Nano))
+		if event.Subject != "" {
+			header.Set("ce-subject", event.Subject)
+		}
+	case ModeStructured:
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		body, contentType = data, contentTypeCloudEventsJSON
+	default:
+		return fmt.Errorf("unknown mode: %q", s.Mode)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, values := range s.Header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	for k, values := range header {
+		req.Header[k] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()        //nolint:errcheck
+	io.Copy(io.Discard, res.Body) //nolint:errcheck
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("sending event %s: unexpected status %s", event.ID, res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"fmt"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/model"
+)
+
+// ContractDeletedData is the data of TypeContractDeleted events.
+type ContractDeletedData struct {
+	ContractID int64 `json:"contract_id"`
+}
+
+// SnapshotDeletedData is the data of TypeSnapshotDeleted events.
+type SnapshotDeletedData struct {
+	DiskID     int64 `json:"disk_id"`
+	SnapshotID int64 `json:"snapshot_id"`
+}
+
+// RestoreAcceptedData is the data of TypeDiskRestoreAccepted events.
+type RestoreAcceptedData struct {
+	DiskID     int64 `json:"disk_id"`
+	SnapshotID int64 `json:"snapshot_id"`
+}
+
+// ExpandAcceptedData is the data of TypeDiskExpandAccepted events.
+type ExpandAcceptedData struct {
+	DiskID         int64 `json:"disk_id"`
+	ExpandedSizeMB int64 `json:"expanded_size_mb"`
+}
+
+func contractSubject(id int64) string {
+	return fmt.Sprintf("contracts/%d", id)
+}
+
+func diskSubject(id int64) string {
+	return fmt.Sprintf("disks/%d", id)
+}
+
+func snapshotSubject(diskID, snapshotID int64) string {
+	return fmt.Sprintf("disks/%d/snapshots/%d", diskID, snapshotID)
+}
+
+var _ dedicatedstorage.ContractAPI = (*contractOp)(nil)
+
+type contractOp struct {
+	dedicatedstorage.ContractAPI
+	emitter *Emitter
+}
+
+// NewContractOp returns a ContractAPI which emits an event for every successful mutating call of api.
+// The data of the events are the model of the resulting resources.
+func NewContractOp(api dedicatedstorage.ContractAPI, emitter *Emitter) dedicatedstorage.ContractAPI {
+	return &contractOp{ContractAPI: api, emitter: emitter}
+}
+
+func (op *contractOp) Create(ctx context.Context, request v1.CreateDedicatedStorageContractRequest) (*v1.DedicatedStorageContract, error) {
+	res, err := op.ContractAPI.Create(ctx, request)
+	if err == nil {
+		op.emitter.emit(ctx, TypeContractCreated, contractSubject(res.ID), model.ContractFromV1(res))
+	}
+	return res, err
+}
+
+func (op *contractOp) Update(ctx context.Context, id int64, request v1.UpdateDedicatedStorageContractRequest) (*v1.DedicatedStorageContract, error) {
+	res, err := op.ContractAPI.Update(ctx, id, request)
+	if err == nil {
+		op.emitter.emit(ctx, TypeContractUpdated, contractSubject(id), model.ContractFromV1(res))
+	}
+	return res, err
+}
+
+func (op *contractOp) Delete(ctx context.Context, id int64) error {
+	err := op.ContractAPI.Delete(ctx, id)
+	if err == nil {
+		op.emitter.emit(ctx, TypeContractDeleted, contractSubject(id), &ContractDeletedData{ContractID: id})
+	}
+	return err
+}
+
+var _ dedicatedstorage.DiskAPI = (*diskOp)(nil)
+
+type diskOp struct {
+	dedicatedstorage.DiskAPI
+	emitter *Emitter
+}
+
+// NewDiskOp returns a DiskAPI which emits an event for every successful mutating call of api.
+// Restores and expansions are reported when accepted, not when completed.
+func NewDiskOp(api dedicatedstorage.DiskAPI, emitter *Emitter) dedicatedstorage.DiskAPI {
+	return &diskOp{DiskAPI: api, emitter: emitter}
+}
+
+func (op *diskOp) CreateSnapshot(ctx context.Context, diskID int64, request *v1.CreateSnapshotRequest) (*v1.DiskSnapshot, error) {
+	res, err := op.DiskAPI.CreateSnapshot(ctx, diskID, request)
+	if err == nil {
+		op.emitter.emit(ctx, TypeSnapshotCreated, snapshotSubject(diskID, res.ID), model.SnapshotFromV1(res))
+	}
+	return res, err
+}
+
+func (op *diskOp) UpdateSnapshot(ctx context.Context, diskID, snapshotID int64, request *v1.UpdateSnapshotRequest) (*v1.DiskSnapshot, error) {
+	res, err := op.DiskAPI.UpdateSnapshot(ctx, diskID, snapshotID, request)
+	if err == nil {
+		op.emitter.emit(ctx, TypeSnapshotUpdated, snapshotSubject(diskID, snapshotID), model.SnapshotFromV1(res))
+	}
+	return res, err
+}
+
+func (op *diskOp) DeleteSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	err := op.DiskAPI.DeleteSnapshot(ctx, diskID, snapshotID)
+	if err == nil {
+		op.emitter.emit(ctx, TypeSnapshotDeleted, snapshotSubject(diskID, snapshotID), &SnapshotDeletedData{DiskID: diskID, SnapshotID: snapshotID})
+	}
+	return err
+}
+
+func (op *diskOp) RestoreFromSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	err := op.DiskAPI.RestoreFromSnapshot(ctx, diskID, snapshotID)
+	if err == nil {
+		op.emitter.emit(ctx, TypeDiskRestoreAccepted, diskSubject(diskID), &RestoreAcceptedData{DiskID: diskID, SnapshotID: snapshotID})
+	}
+	return err
+}
+
+func (op *diskOp) Expand(ctx context.Context, diskID int64, request *v1.ExpandDiskRequest) error {
+	err := op.DiskAPI.Expand(ctx, diskID, request)
+	if err == nil {
+		op.emitter.emit(ctx, TypeDiskExpandAccepted, diskSubject(diskID), &ExpandAcceptedData{DiskID: diskID, ExpandedSizeMB: request.ExpanedSizeMB})
+	}
+	return err
+}
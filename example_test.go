@@ -21,6 +21,7 @@ import (
 
 	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
 	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
 	"github.com/sacloud/saclient-go"
 )
 
@@ -95,3 +96,35 @@ func Example_dedicatedStorageCRUDL() {
 		panic(err)
 	}
 }
+
+func ExampleContractCreateParams() {
+	store := fake.NewStore()
+	store.AddPlan(v1.DedicatedStorageContractPlan{ID: 1, Name: "standard"})
+	contractOp := fake.NewContractOp(store)
+	ctx := context.Background()
+
+	// create
+	createParams := &dedicatedstorage.ContractCreateParams{
+		PlanID:      1,
+		Name:        "example-name",
+		Description: "example-description",
+		Tags:        []string{"example1", "example2"},
+		IconID:      111111111111,
+	}
+	created, err := contractOp.Create(ctx, createParams.Request())
+	if err != nil {
+		panic(err)
+	}
+
+	// update: start from the current contract so that only the changed fields are modified
+	updateParams := dedicatedstorage.ContractUpdateParamsOf(created)
+	updateParams.Name = "example-name-updated"
+	updateParams.IconID = 0 // remove the icon
+	updated, err := contractOp.Update(ctx, created.ID, updateParams.Request())
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(updated.Name, updated.Description, updated.Tags, dedicatedstorage.ContractIconID(updated))
+	// Output:
+	// example-name-updated example-description [example1 example2] 0
+}
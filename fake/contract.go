@@ -0,0 +1,228 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+)
+
+var _ dedicatedstorage.ContractAPI = (*contractOp)(nil)
+
+type contractOp struct {
+	store *Store
+}
+
+// NewContractOp returns a ContractAPI backed by the store.
+func NewContractOp(store *Store) dedicatedstorage.ContractAPI {
+	return &contractOp{store: store}
+}
+
+func (op *contractOp) Create(_ context.Context, request v1.CreateDedicatedStorageContractRequest) (*v1.DedicatedStorageContract, error) {
+	const methodName = "Contract.Create"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+	if err := dedicatedstorage.ValidateCreateContractRequest(&request); err != nil {
+		return nil, badRequest(methodName, err.Error())
+	}
+
+	req := request.DedicatedStorageContract
+	plan, ok := s.plans[req.Plan.ID]
+	if !ok {
+		return nil, notFound(methodName, "plan", req.Plan.ID)
+	}
+	contract := v1.DedicatedStorageContract{
+		ID:          s.newID(),
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedAt:   v1.NewNilDateTime(s.now()),
+		Plan:        v1.Plan(plan),
+		Icon:        req.Icon,
+		Tags:        append([]string{}, req.Tags...),
+	}
+	s.contracts[contract.ID] = contract
+	return &contract, nil
+}
+
+func (op *contractOp) List(_ context.Context) (*v1.DedicatedStorageContractsListResponse, error) {
+	const methodName = "Contract.List"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+
+	contracts := []v1.DedicatedStorageContract{}
+	for _, v := range s.contracts {
+		contracts = append(contracts, v)
+	}
+	sort.Slice(contracts, func(i, j int) bool { return contracts[i].ID < contracts[j].ID })
+	return &v1.DedicatedStorageContractsListResponse{
+		DedicatedStorageContracts: contracts,
+		Count:                     int32(len(contracts)), //nolint:gosec
+		Total:                     int64(len(contracts)),
+		IsOk:                      true,
+	}, nil
+}
+
+func (op *contractOp) Read(_ context.Context, id int64) (*v1.DedicatedStorageContract, error) {
+	const methodName = "Contract.Read"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+
+	contract, ok := s.contracts[id]
+	if !ok {
+		return nil, notFound(methodName, "contract", id)
+	}
+	return &contract, nil
+}
+
+func (op *contractOp) Update(_ context.Context, id int64, request v1.UpdateDedicatedStorageContractRequest) (*v1.DedicatedStorageContract, error) {
+	const methodName = "Contract.Update"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+	if err := dedicatedstorage.ValidateUpdateContractRequest(id, &request); err != nil {
+		return nil, badRequest(methodName, err.Error())
+	}
+
+	contract, ok := s.contracts[id]
+	if !ok {
+		return nil, notFound(methodName, "contract", id)
+	}
+	req := request.DedicatedStorageContract
+	contract.Name = req.Name
+	contract.Description = req.Description
+	contract.Tags = append([]string{}, req.Tags...)
+	// an unset icon is kept as it is, like the API does
+	if req.Icon.Set {
+		contract.Icon = req.Icon
+	}
+	s.contracts[id] = contract
+	return &contract, nil
+}
+
+func (op *contractOp) Delete(_ context.Context, id int64) error {
+	const methodName = "Contract.Delete"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return err
+	}
+
+	if _, ok := s.contracts[id]; !ok {
+		return notFound(methodName, "contract", id)
+	}
+	for _, v := range s.snapshots {
+		if v.DedicatedStorageContract.ID == id {
+			return dedicatedstorage.NewAPIError(methodName, 409, errors.New("conflict"))
+		}
+	}
+	delete(s.contracts, id)
+	delete(s.usages, id)
+	return nil
+}
+
+func (op *contractOp) PoolUsage(_ context.Context, id int64) (*v1.PoolUsageResponsePoolUsage, error) {
+	const methodName = "Contract.PoolUsage"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+
+	if _, ok := s.contracts[id]; !ok {
+		return nil, notFound(methodName, "contract", id)
+	}
+	usage := s.usages[id]
+	return &usage, nil
+}
+
+func (op *contractOp) ListDiskSnapshots(_ context.Context, id int64) (*v1.DiskSnapshotsListResponse, error) {
+	const methodName = "Contract.DiskSnapshots"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+
+	if _, ok := s.contracts[id]; !ok {
+		return nil, notFound(methodName, "contract", id)
+	}
+	snapshots := s.listSnapshots(func(v v1.DiskSnapshot) bool { return v.DedicatedStorageContract.ID == id })
+	return &v1.DiskSnapshotsListResponse{
+		DiskSnapshots: snapshots,
+		Count:         int64(len(snapshots)),
+		Total:         int64(len(snapshots)),
+		IsOk:          true,
+	}, nil
+}
+
+func (op *contractOp) ListPlans(_ context.Context) (*v1.DedicatedStorageContractPlanListResponse, error) {
+	const methodName = "Contract.ListPlans"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+
+	plans := []v1.DedicatedStorageContractPlan{}
+	for _, v := range s.plans {
+		plans = append(plans, v)
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].ID < plans[j].ID })
+	return &v1.DedicatedStorageContractPlanListResponse{
+		DedicatedStorageContractPlans: plans,
+		Count:                         int64(len(plans)),
+		Total:                         int64(len(plans)),
+		IsOk:                          true,
+	}, nil
+}
+
+func (op *contractOp) ReadPlan(_ context.Context, planID int64) (*v1.DedicatedStorageContractPlan, error) {
+	const methodName = "Contract.ReadPlan"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+
+	plan, ok := s.plans[planID]
+	if !ok {
+		return nil, notFound(methodName, "plan", planID)
+	}
+	return &plan, nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"context"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+)
+
+var _ dedicatedstorage.DiskAPI = (*diskOp)(nil)
+
+type diskOp struct {
+	store *Store
+}
+
+// NewDiskOp returns a DiskAPI backed by the store.
+func NewDiskOp(store *Store) dedicatedstorage.DiskAPI {
+	return &diskOp{store: store}
+}
+
+func (op *diskOp) CreateSnapshot(_ context.Context, diskID int64, request *v1.CreateSnapshotRequest) (*v1.DiskSnapshot, error) {
+	const methodName = "Disk.CreateSnapshot"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+	if err := dedicatedstorage.ValidateCreateSnapshotRequest(diskID, request); err != nil {
+		return nil, badRequest(methodName, err.Error())
+	}
+
+	disk, ok := s.disks[diskID]
+	if !ok {
+		return nil, notFound(methodName, "disk", diskID)
+	}
+	req := request.DiskSnapshot
+	if _, ok := s.contracts[req.DedicatedStorageContract.ID]; !ok {
+		return nil, notFound(methodName, "contract", req.DedicatedStorageContract.ID)
+	}
+	state := s.InitialSnapshotState
+	if state == "" {
+		state = DefaultSnapshotState
+	}
+	snapshot := v1.DiskSnapshot{
+		ID:                       s.newID(),
+		Name:                     req.Name,
+		Description:              req.Description,
+		CreatedAt:                s.now(),
+		SnapshotState:            string(state),
+		Disk:                     disk,
+		DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: req.DedicatedStorageContract.ID},
+	}
+	s.snapshots[snapshot.ID] = snapshot
+	return &snapshot, nil
+}
+
+func (op *diskOp) ListSnapshots(_ context.Context, diskID int64) (*v1.DiskSnapshotsListResponse, error) {
+	const methodName = "Disk.ListSnapshots"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+
+	if _, ok := s.disks[diskID]; !ok {
+		return nil, notFound(methodName, "disk", diskID)
+	}
+	snapshots := s.listSnapshots(func(v v1.DiskSnapshot) bool { return v.Disk.ID == diskID })
+	return &v1.DiskSnapshotsListResponse{
+		DiskSnapshots: snapshots,
+		Count:         int64(len(snapshots)),
+		Total:         int64(len(snapshots)),
+		IsOk:          true,
+	}, nil
+}
+
+func (op *diskOp) UpdateSnapshot(_ context.Context, diskID, snapshotID int64, request *v1.UpdateSnapshotRequest) (*v1.DiskSnapshot, error) {
+	const methodName = "Disk.UpdateSnapshot"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+	if err := dedicatedstorage.ValidateUpdateSnapshotRequest(diskID, snapshotID, request); err != nil {
+		return nil, badRequest(methodName, err.Error())
+	}
+
+	snapshot, err := s.diskSnapshot(methodName, diskID, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Name = request.DiskSnapshot.Name
+	snapshot.Description = request.DiskSnapshot.Description
+	s.snapshots[snapshotID] = snapshot
+	return &snapshot, nil
+}
+
+func (op *diskOp) DeleteSnapshot(_ context.Context, diskID, snapshotID int64) error {
+	const methodName = "Disk.DeleteSnapshot"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return err
+	}
+
+	if _, err := s.diskSnapshot(methodName, diskID, snapshotID); err != nil {
+		return err
+	}
+	s.deleteSnapshot(snapshotID)
+	return nil
+}
+
+func (op *diskOp) RestoreFromSnapshot(_ context.Context, diskID, snapshotID int64) error {
+	const methodName = "Disk.RestoreFromSnapshot"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return err
+	}
+
+	if _, err := s.diskSnapshot(methodName, diskID, snapshotID); err != nil {
+		return err
+	}
+	s.startOperation(diskID)
+	return nil
+}
+
+func (op *diskOp) Expand(_ context.Context, diskID int64, request *v1.ExpandDiskRequest) error {
+	const methodName = "Disk.Expand"
+	s := op.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return err
+	}
+	if err := dedicatedstorage.ValidateExpandDiskRequest(diskID, request); err != nil {
+		return badRequest(methodName, err.Error())
+	}
+
+	disk, ok := s.disks[diskID]
+	if !ok {
+		return notFound(methodName, "disk", diskID)
+	}
+	if request.ExpanedSizeMB <= disk.SizeMB {
+		return badRequest(methodName, "invalid size")
+	}
+	disk.SizeMB = request.ExpanedSizeMB
+	s.disks[diskID] = disk
+	s.startOperation(diskID)
+	return nil
+}
+
+// diskSnapshot returns the snapshot taken from the disk. The caller must hold s.mu.
+func (s *Store) diskSnapshot(method string, diskID, snapshotID int64) (v1.DiskSnapshot, error) {
+	if _, ok := s.disks[diskID]; !ok {
+		return v1.DiskSnapshot{}, notFound(method, "disk", diskID)
+	}
+	snapshot, ok := s.snapshots[snapshotID]
+	if !ok || snapshot.Disk.ID != diskID {
+		return v1.DiskSnapshot{}, notFound(method, "snapshot", snapshotID)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"context"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+)
+
+var _ dedicatedstorage.DiskStateReader = (*diskStateReader)(nil)
+
+type diskStateReader struct {
+	store *Store
+}
+
+// NewDiskStateReader returns a DiskStateReader reading the current disks of the store.
+// Every read moves disks under operation to their next state, see Store.OperationStates.
+func NewDiskStateReader(store *Store) dedicatedstorage.DiskStateReader {
+	return &diskStateReader{store: store}
+}
+
+func (r *diskStateReader) ReadDisk(_ context.Context, diskID int64) (*v1.Disk, error) {
+	const methodName = "Disk.Read"
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.begin(methodName); err != nil {
+		return nil, err
+	}
+
+	disk, ok := s.disks[diskID]
+	if !ok {
+		return nil, notFound(methodName, "disk", diskID)
+	}
+	if transition := s.transitions[diskID]; len(transition) > 0 {
+		disk.Availability = string(transition[0])
+		s.disks[diskID] = disk
+		s.transitions[diskID] = transition[1:]
+	}
+	return &disk, nil
+}
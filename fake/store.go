@@ -0,0 +1,272 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fake provides in-memory implementations of dedicatedstorage.ContractAPI
+// and dedicatedstorage.DiskAPI for use in tests.
+package fake
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+)
+
+// DefaultSnapshotState is the state given to snapshots created through the fake DiskAPI
+// unless Store.InitialSnapshotState is set.
+const DefaultSnapshotState = types.SnapshotStateAvailable
+
+// Store holds the state shared by the fake ContractAPI and DiskAPI implementations.
+type Store struct {
+	// InitialSnapshotState is the SnapshotState of newly created snapshots.
+	InitialSnapshotState types.SnapshotState
+	// OperationStates are the availabilities a disk goes through after RestoreFromSnapshot or Expand,
+	// one per read by the DiskStateReader, before it is available again. The disk stays as it is when nil.
+	// A leading AvailabilityAvailable delays the start of the operation.
+	OperationStates []types.Availability
+	// DeletingReads is the number of listings a deleted snapshot remains in, in the deleting state,
+	// before it disappears. Snapshots disappear at once when zero.
+	DeletingReads int
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+
+	mu        sync.Mutex
+	nextID    int64
+	plans     map[int64]v1.DedicatedStorageContractPlan
+	contracts map[int64]v1.DedicatedStorageContract
+	usages    map[int64]v1.PoolUsageResponsePoolUsage
+	disks     map[int64]v1.Disk
+	snapshots map[int64]v1.DiskSnapshot
+	// transitions are the availabilities the disks will go through
+	transitions map[int64][]types.Availability
+	// deleting are the numbers of listings left to the snapshots being deleted
+	deleting map[int64]int
+	failures map[string]error
+	calls    []string
+}
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	return &Store{
+		nextID:      100000000000,
+		plans:       make(map[int64]v1.DedicatedStorageContractPlan),
+		contracts:   make(map[int64]v1.DedicatedStorageContract),
+		usages:      make(map[int64]v1.PoolUsageResponsePoolUsage),
+		disks:       make(map[int64]v1.Disk),
+		snapshots:   make(map[int64]v1.DiskSnapshot),
+		transitions: make(map[int64][]types.Availability),
+		deleting:    make(map[int64]int),
+		failures:    make(map[string]error),
+	}
+}
+
+// AddPlan registers a contract plan.
+func (s *Store) AddPlan(plan v1.DedicatedStorageContractPlan) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plans[plan.ID] = plan
+}
+
+// AddContract registers a contract as is. A zero ID is replaced with a generated one.
+func (s *Store) AddContract(contract v1.DedicatedStorageContract) v1.DedicatedStorageContract {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if contract.ID == 0 {
+		contract.ID = s.newID()
+	}
+	s.contracts[contract.ID] = contract
+	return contract
+}
+
+// AddDisk registers a disk which snapshots can be taken from. A zero ID is replaced with a generated one.
+func (s *Store) AddDisk(disk v1.Disk) v1.Disk {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if disk.ID == 0 {
+		disk.ID = s.newID()
+	}
+	s.disks[disk.ID] = disk
+	return disk
+}
+
+// RemoveDisk removes a disk while keeping its snapshots, as happens when a disk is deleted outside this API.
+func (s *Store) RemoveDisk(diskID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.disks, diskID)
+}
+
+// AddSnapshot registers a snapshot as is. A zero ID is replaced with a generated one.
+func (s *Store) AddSnapshot(snapshot v1.DiskSnapshot) v1.DiskSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if snapshot.ID == 0 {
+		snapshot.ID = s.newID()
+	}
+	s.snapshots[snapshot.ID] = snapshot
+	return snapshot
+}
+
+// SetPoolUsage sets the value returned from ContractAPI.PoolUsage for the contract.
+func (s *Store) SetPoolUsage(contractID int64, usage v1.PoolUsageResponsePoolUsage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usages[contractID] = usage
+}
+
+// SetSnapshotState changes the SnapshotState of a stored snapshot.
+func (s *Store) SetSnapshotState(snapshotID int64, state types.SnapshotState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.snapshots[snapshotID]; ok {
+		v.SnapshotState = string(state)
+		s.snapshots[snapshotID] = v
+	}
+}
+
+// Contract returns the stored contract.
+func (s *Store) Contract(id int64) (v1.DedicatedStorageContract, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.contracts[id]
+	return v, ok
+}
+
+// Disk returns the stored disk.
+func (s *Store) Disk(id int64) (v1.Disk, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.disks[id]
+	return v, ok
+}
+
+// Snapshot returns the stored snapshot.
+func (s *Store) Snapshot(id int64) (v1.DiskSnapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.snapshots[id]
+	return v, ok
+}
+
+// Snapshots returns all stored snapshots ordered by ID.
+func (s *Store) Snapshots() []v1.DiskSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedSnapshots(func(v1.DiskSnapshot) bool { return true })
+}
+
+// Fail makes every following call of the method fail with err. method is the name used in
+// error messages such as "Disk.CreateSnapshot". A nil err clears the failure.
+func (s *Store) Fail(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.failures, method)
+		return
+	}
+	s.failures[method] = err
+}
+
+// Calls returns the names of the methods called so far, in order.
+func (s *Store) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func (s *Store) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *Store) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// begin records the call and returns the injected failure for the method, if any.
+// The caller must hold s.mu.
+func (s *Store) begin(method string) error {
+	s.calls = append(s.calls, method)
+	if err, ok := s.failures[method]; ok {
+		return dedicatedstorage.NewAPIError(method, 0, err)
+	}
+	return nil
+}
+
+// startOperation makes the disk go through OperationStates. The caller must hold s.mu.
+func (s *Store) startOperation(diskID int64) {
+	if s.OperationStates != nil {
+		s.transitions[diskID] = append(slices.Clone(s.OperationStates), types.AvailabilityAvailable)
+	}
+}
+
+// deleteSnapshot deletes the snapshot, or starts deleting it when DeletingReads is set. The caller must hold s.mu.
+func (s *Store) deleteSnapshot(snapshotID int64) {
+	if s.DeletingReads <= 0 {
+		delete(s.snapshots, snapshotID)
+		return
+	}
+	if _, ok := s.deleting[snapshotID]; ok {
+		return
+	}
+	snapshot := s.snapshots[snapshotID]
+	snapshot.SnapshotState = string(types.SnapshotStateDeleting)
+	s.snapshots[snapshotID] = snapshot
+	s.deleting[snapshotID] = s.DeletingReads
+}
+
+// listSnapshots returns the snapshots matching filter and counts the listing for the snapshots being deleted.
+// The caller must hold s.mu.
+func (s *Store) listSnapshots(filter func(v1.DiskSnapshot) bool) []v1.DiskSnapshot {
+	snapshots := s.sortedSnapshots(filter)
+	for _, v := range snapshots {
+		if left, ok := s.deleting[v.ID]; ok {
+			if left <= 1 {
+				delete(s.deleting, v.ID)
+				delete(s.snapshots, v.ID)
+			} else {
+				s.deleting[v.ID] = left - 1
+			}
+		}
+	}
+	return snapshots
+}
+
+func (s *Store) sortedSnapshots(filter func(v1.DiskSnapshot) bool) []v1.DiskSnapshot {
+	results := []v1.DiskSnapshot{}
+	for _, v := range s.snapshots {
+		if filter(v) {
+			results = append(results, v)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+	return results
+}
+
+func notFound(method, kind string, id int64) error {
+	return dedicatedstorage.NewAPIError(method, 404, fmt.Errorf("%s %d not found", kind, id))
+}
+
+func badRequest(method, msg string) error {
+	return dedicatedstorage.NewAPIError(method, 400, errors.New(msg))
+}
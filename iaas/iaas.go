@@ -0,0 +1,78 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package iaas connects the dedicated storage API with the disks and servers of the IaaS API.
+//
+// It resolves disks by name or tag, finds the server a disk is attached to, and stops and
+// starts that server around DiskAPI.RestoreFromSnapshot and DiskAPI.Expand.
+// It does not depend on sacloud/iaas-api-go: DiskAPI and ServerAPI are small interfaces
+// which are implemented with a few lines on top of its DiskOp and ServerOp.
+package iaas
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sacloud/dedicated-storage-api-go/types"
+)
+
+var (
+	// ErrDiskNotFound is returned when no disk matches.
+	ErrDiskNotFound = errors.New("disk not found")
+	// ErrAmbiguousDisk is returned when more than one disk matches a name.
+	ErrAmbiguousDisk = errors.New("more than one disk matches")
+)
+
+// Disk is a disk of the IaaS API.
+type Disk struct {
+	ID           int64
+	Name         string
+	Tags         []string
+	SizeMB       int64
+	Availability types.Availability
+	// ServerID is the server the disk is attached to, zero when detached
+	ServerID int64
+	// ConnectionOrder is the position of the disk on its server, zero when detached
+	ConnectionOrder int64
+}
+
+// ServerStatus is the instance status of a server.
+type ServerStatus string
+
+const (
+	ServerStatusUp   ServerStatus = "up"
+	ServerStatusDown ServerStatus = "down"
+)
+
+// Server is a server of the IaaS API.
+type Server struct {
+	ID     int64
+	Name   string
+	Status ServerStatus
+}
+
+// DiskAPI reads the disks of the IaaS API, e.g. with iaas.DiskOp of sacloud/iaas-api-go.
+type DiskAPI interface {
+	ListDisks(ctx context.Context) ([]*Disk, error)
+	ReadDisk(ctx context.Context, id int64) (*Disk, error)
+}
+
+// ServerAPI operates the servers of the IaaS API, e.g. with iaas.ServerOp of sacloud/iaas-api-go.
+type ServerAPI interface {
+	ReadServer(ctx context.Context, id int64) (*Server, error)
+	// Shutdown requests a graceful shutdown of the server without waiting for it.
+	Shutdown(ctx context.Context, id int64) error
+	// Boot requests a boot of the server without waiting for it.
+	Boot(ctx context.Context, id int64) error
+}
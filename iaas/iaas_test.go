@@ -0,0 +1,207 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iaas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeIaaS struct {
+	mu      sync.Mutex
+	disks   []*Disk
+	servers map[int64]*Server
+	calls   []string
+	// pending are the availabilities the disks go through on the next reads
+	pending map[int64][]types.Availability
+	// shutdownStatus is the status servers are left in by Shutdown, ServerStatusDown when empty
+	shutdownStatus ServerStatus
+}
+
+func (f *fakeIaaS) ListDisks(context.Context) ([]*Disk, error) {
+	return f.disks, nil
+}
+
+func (f *fakeIaaS) ReadDisk(_ context.Context, id int64) (*Disk, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, disk := range f.disks {
+		if disk.ID == id {
+			if pending := f.pending[id]; len(pending) > 0 {
+				disk.Availability, f.pending[id] = pending[0], pending[1:]
+			}
+			return disk, nil
+		}
+	}
+	return nil, fmt.Errorf("disk %d not found", id)
+}
+
+func (f *fakeIaaS) ReadServer(_ context.Context, id int64) (*Server, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	server, ok := f.servers[id]
+	if !ok {
+		return nil, fmt.Errorf("server %d not found", id)
+	}
+	copied := *server
+	return &copied, nil
+}
+
+func (f *fakeIaaS) setStatus(id int64, call string, status ServerStatus) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	f.servers[id].Status = status
+	return nil
+}
+
+func (f *fakeIaaS) Shutdown(_ context.Context, id int64) error {
+	if f.shutdownStatus != "" {
+		return f.setStatus(id, "shutdown", f.shutdownStatus)
+	}
+	return f.setStatus(id, "shutdown", ServerStatusDown)
+}
+
+func (f *fakeIaaS) Boot(_ context.Context, id int64) error {
+	return f.setStatus(id, "boot", ServerStatusUp)
+}
+
+func newFakeIaaS() *fakeIaaS {
+	return &fakeIaaS{
+		disks: []*Disk{
+			{ID: 1, Name: "web", Tags: []string{"app"}, Availability: types.AvailabilityAvailable, ServerID: 10, ConnectionOrder: 1},
+			{ID: 2, Name: "db", Tags: []string{"app"}, Availability: types.AvailabilityAvailable},
+			{ID: 3, Name: "db", Availability: types.AvailabilityAvailable},
+		},
+		servers: map[int64]*Server{10: {ID: 10, Name: "web", Status: ServerStatusUp}},
+		pending: map[int64][]types.Availability{},
+	}
+}
+
+// migratingDiskOp makes the IaaS disks migrate for a while after restores
+type migratingDiskOp struct {
+	dedicatedstorage.DiskAPI
+	iaas *fakeIaaS
+}
+
+func (op *migratingDiskOp) RestoreFromSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	if err := op.DiskAPI.RestoreFromSnapshot(ctx, diskID, snapshotID); err != nil {
+		return err
+	}
+	op.iaas.mu.Lock()
+	defer op.iaas.mu.Unlock()
+	op.iaas.pending[diskID] = []types.Availability{types.AvailabilityMigrating, types.AvailabilityAvailable}
+	return nil
+}
+
+func TestIntegration_Find(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+	integration := NewIntegration(newFakeIaaS(), nil)
+
+	disk, err := integration.FindDiskByName(ctx, "web")
+	assert.NoError(err)
+	assert.EqualValues(1, disk.ID)
+	_, err = integration.FindDiskByName(ctx, "db")
+	assert.True(errors.Is(err, ErrAmbiguousDisk))
+	_, err = integration.FindDiskByName(ctx, "missing")
+	assert.True(errors.Is(err, ErrDiskNotFound))
+
+	disks, err := integration.FindDisksByTag(ctx, "app")
+	assert.NoError(err)
+	assert.Len(disks, 2)
+	_, err = integration.FindDisksByTag(ctx, "missing")
+	assert.True(errors.Is(err, ErrDiskNotFound))
+
+	exists, err := integration.DiskExists(ctx, 2)
+	assert.NoError(err)
+	assert.True(exists)
+	exists, err = integration.DiskExists(ctx, 9)
+	assert.NoError(err)
+	assert.False(exists)
+}
+
+func TestIntegration_AttachedServer(t *testing.T) {
+	assert := require.New(t)
+	iaas := newFakeIaaS()
+	integration := NewIntegration(iaas, iaas)
+
+	server, err := integration.AttachedServer(t.Context(), 1)
+	assert.NoError(err)
+	assert.EqualValues(10, server.ID)
+
+	server, err = integration.AttachedServer(t.Context(), 2)
+	assert.NoError(err)
+	assert.Nil(server)
+
+	disk, err := integration.ReadDisk(t.Context(), 2)
+	assert.NoError(err)
+	assert.True(disk.ConnectionOrder.Null)
+}
+
+func TestNewGuardedDiskOp(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+
+	iaas := newFakeIaaS()
+	integration := NewIntegration(iaas, iaas)
+	integration.WaitInterval = time.Millisecond
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	disk := store.AddDisk(v1.Disk{ID: 1})
+	snapshot := store.AddSnapshot(v1.DiskSnapshot{
+		Disk:                     disk,
+		DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+	})
+
+	op := NewGuardedDiskOp(&migratingDiskOp{DiskAPI: fake.NewDiskOp(store), iaas: iaas}, integration)
+	assert.NoError(op.RestoreFromSnapshot(ctx, disk.ID, snapshot.ID))
+	assert.Equal([]string{"shutdown", "boot"}, iaas.calls)
+	assert.Equal(ServerStatusUp, iaas.servers[10].Status)
+
+	// a server which was down before stays down
+	iaas.calls = nil
+	iaas.servers[10].Status = ServerStatusDown
+	assert.NoError(op.RestoreFromSnapshot(ctx, disk.ID, snapshot.ID))
+	assert.Empty(iaas.calls)
+	assert.Equal(ServerStatusDown, iaas.servers[10].Status)
+
+	t.Run("server does not go down", func(t *testing.T) {
+		assert := require.New(t)
+		iaas.calls = nil
+		iaas.servers[10].Status = ServerStatusUp
+		iaas.shutdownStatus = "cleaning"
+		defer func() { iaas.shutdownStatus = "" }()
+		integration.WaitTimeout = 20 * time.Millisecond
+		defer func() { integration.WaitTimeout = 0 }()
+		calls := len(store.Calls())
+
+		assert.Error(op.RestoreFromSnapshot(ctx, disk.ID, snapshot.ID))
+		assert.Equal([]string{"shutdown", "boot"}, iaas.calls, "the server is brought back")
+		assert.Equal(ServerStatusUp, iaas.servers[10].Status)
+		assert.NotContains(store.Calls()[calls:], "Disk.RestoreFromSnapshot")
+	})
+}
@@ -0,0 +1,223 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iaas
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/packages-go/wait"
+)
+
+const (
+	DefaultServerWaitInterval = 5 * time.Second
+	DefaultServerWaitTimeout  = 10 * time.Minute
+)
+
+var (
+	_ dedicatedstorage.DiskStateReader          = (*Integration)(nil)
+	_ dedicatedstorage.AttachedServerController = (*Integration)(nil)
+)
+
+// Integration resolves disks and controls their servers through the IaaS API.
+//
+// It is a dedicatedstorage.DiskStateReader and a dedicatedstorage.AttachedServerController,
+// so it can be given to dedicatedstorage.NewGuardedDiskOp, see NewGuardedDiskOp.
+type Integration struct {
+	Disks   DiskAPI
+	Servers ServerAPI
+	// WaitInterval is the polling interval while waiting for servers. DefaultServerWaitInterval is used when zero.
+	WaitInterval time.Duration
+	// WaitTimeout is the maximum time to wait for a server. DefaultServerWaitTimeout is used when zero.
+	WaitTimeout time.Duration
+}
+
+// NewIntegration returns an Integration with the default wait settings.
+func NewIntegration(disks DiskAPI, servers ServerAPI) *Integration {
+	return &Integration{Disks: disks, Servers: servers}
+}
+
+// NewGuardedDiskOp returns a DiskAPI whose RestoreFromSnapshot and Expand read the disk through
+// the IaaS API and stop its server during the operation when it is attached.
+// The wait settings of integration also apply to waiting for the disk before restarting the server.
+func NewGuardedDiskOp(api dedicatedstorage.DiskAPI, integration *Integration) dedicatedstorage.DiskAPI {
+	return dedicatedstorage.NewGuardedDiskOp(api, &dedicatedstorage.DiskGuardOptions{
+		StateReader:      integration,
+		ServerController: integration,
+		WaitInterval:     integration.WaitInterval,
+		WaitTimeout:      integration.WaitTimeout,
+	})
+}
+
+// FindDiskByName returns the disk named name. It fails with ErrDiskNotFound or ErrAmbiguousDisk
+// unless exactly one disk has the name.
+func (i *Integration) FindDiskByName(ctx context.Context, name string) (*Disk, error) {
+	const methodName = "IaaS.FindDiskByName"
+
+	disks, err := i.Disks.ListDisks(ctx)
+	if err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+	var found []*Disk
+	for _, disk := range disks {
+		if disk.Name == name {
+			found = append(found, disk)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, dedicatedstorage.NewError(methodName, fmt.Errorf("%w: %q", ErrDiskNotFound, name))
+	case 1:
+		return found[0], nil
+	default:
+		return nil, dedicatedstorage.NewError(methodName, fmt.Errorf("%w: %d disks named %q", ErrAmbiguousDisk, len(found), name))
+	}
+}
+
+// FindDisksByTag returns the disks having tag, in the order the IaaS API lists them.
+// It fails with ErrDiskNotFound when no disk has it.
+func (i *Integration) FindDisksByTag(ctx context.Context, tag string) ([]*Disk, error) {
+	const methodName = "IaaS.FindDisksByTag"
+
+	disks, err := i.Disks.ListDisks(ctx)
+	if err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+	var found []*Disk
+	for _, disk := range disks {
+		if slices.Contains(disk.Tags, tag) {
+			found = append(found, disk)
+		}
+	}
+	if len(found) == 0 {
+		return nil, dedicatedstorage.NewError(methodName, fmt.Errorf("%w: tag %q", ErrDiskNotFound, tag))
+	}
+	return found, nil
+}
+
+// AttachedServer returns the server the disk is attached to, nil when it is detached.
+func (i *Integration) AttachedServer(ctx context.Context, diskID int64) (*Server, error) {
+	const methodName = "IaaS.AttachedServer"
+
+	disk, err := i.Disks.ReadDisk(ctx, diskID)
+	if err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+	if disk.ServerID == 0 {
+		return nil, nil
+	}
+	server, err := i.Servers.ReadServer(ctx, disk.ServerID)
+	if err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+	return server, nil
+}
+
+// DiskExists tells whether the disk exists. It looks the disk up in ListDisks, so that a failure
+// of ReadDisk is never taken for a deleted disk. It is an inventory.DiskChecker.
+func (i *Integration) DiskExists(ctx context.Context, diskID int64) (bool, error) {
+	disks, err := i.Disks.ListDisks(ctx)
+	if err != nil {
+		return false, dedicatedstorage.NewError("IaaS.DiskExists", err)
+	}
+	return slices.ContainsFunc(disks, func(disk *Disk) bool { return disk.ID == diskID }), nil
+}
+
+// ReadDisk reads the disk through the IaaS API as a v1.Disk carrying its availability and connection.
+func (i *Integration) ReadDisk(ctx context.Context, diskID int64) (*v1.Disk, error) {
+	disk, err := i.Disks.ReadDisk(ctx, diskID)
+	if err != nil {
+		return nil, dedicatedstorage.NewError("IaaS.ReadDisk", err)
+	}
+	res := &v1.Disk{
+		ID:              disk.ID,
+		Name:            disk.Name,
+		SizeMB:          disk.SizeMB,
+		Availability:    string(disk.Availability),
+		ConnectionOrder: v1.NewNilInt64(disk.ConnectionOrder),
+	}
+	if disk.ServerID == 0 {
+		res.ConnectionOrder.SetToNull()
+	}
+	return res, nil
+}
+
+// StopAttachedServer shuts down the server the disk is attached to and waits until it is down.
+// The returned function boots the server again, unless it was already down before. It is also returned
+// with the error when the server does not go down after the shutdown was accepted.
+func (i *Integration) StopAttachedServer(ctx context.Context, disk *v1.Disk) (func(ctx context.Context) error, error) {
+	const methodName = "IaaS.StopAttachedServer"
+
+	server, err := i.AttachedServer(ctx, disk.ID)
+	if err != nil {
+		return nil, err
+	}
+	if server == nil || server.Status == ServerStatusDown {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	restart := func(ctx context.Context) error {
+		const methodName = "IaaS.RestartServer"
+		// the server may still be up when stopping it failed half way
+		current, err := i.Servers.ReadServer(ctx, server.ID)
+		if err != nil {
+			return dedicatedstorage.NewError(methodName, err)
+		}
+		if current.Status == ServerStatusUp {
+			return nil
+		}
+		if err := i.Servers.Boot(ctx, server.ID); err != nil {
+			return dedicatedstorage.NewError(methodName, err)
+		}
+		if err := i.waitForStatus(ctx, server.ID, ServerStatusUp); err != nil {
+			return dedicatedstorage.NewError(methodName, err)
+		}
+		return nil
+	}
+
+	if err := i.Servers.Shutdown(ctx, server.ID); err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+	// the shutdown was accepted, so the server is brought back even when it does not go down in time
+	if err := i.waitForStatus(ctx, server.ID, ServerStatusDown); err != nil {
+		return restart, dedicatedstorage.NewError(methodName, err)
+	}
+	return restart, nil
+}
+
+func (i *Integration) waitForStatus(ctx context.Context, serverID int64, status ServerStatus) error {
+	waiter := &wait.PollingWaiter{
+		ReadFunc: func() (interface{}, error) {
+			return i.Servers.ReadServer(ctx, serverID)
+		},
+		StateCheckFunc: func(target interface{}) (bool, error) {
+			return target.(*Server).Status == status, nil
+		},
+		Interval: i.WaitInterval,
+		Timeout:  i.WaitTimeout,
+	}
+	if waiter.Interval <= 0 {
+		waiter.Interval = DefaultServerWaitInterval
+	}
+	if waiter.Timeout <= 0 {
+		waiter.Timeout = DefaultServerWaitTimeout
+	}
+	_, err := waiter.WaitForState(ctx)
+	return err
+}
@@ -0,0 +1,69 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fileutil has the file helpers shared by the file based stores.
+package fileutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// lockRetryDelay is the interval between attempts to lock a file while another process holds it
+const lockRetryDelay = 10 * time.Millisecond
+
+// WriteFile writes data to a temporary file in the directory of path and renames it to path,
+// so that readers and crashes never see a partially written file.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck,gosec
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close() //nolint:errcheck,gosec
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Lock takes the flock of path, waiting while another process holds it, and returns the function which releases it.
+// It returns the error of ctx when ctx is done first.
+func Lock(ctx context.Context, path string) (unlock func(), err error) {
+	fl := flock.New(path)
+	locked, err := fl.TryLockContext(ctx, lockRetryDelay)
+	if err != nil {
+		fl.Close() //nolint:errcheck,gosec
+		return nil, err
+	}
+	if !locked {
+		fl.Close() //nolint:errcheck,gosec
+		return nil, ctx.Err()
+	}
+	return func() {
+		fl.Unlock() //nolint:errcheck,gosec
+		fl.Close()  //nolint:errcheck,gosec
+	}, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile(t *testing.T) {
+	assert := require.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	assert.NoError(WriteFile(path, []byte("first"), 0o600))
+	assert.NoError(WriteFile(path, []byte("second"), 0o600))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("second", string(data))
+	info, err := os.Stat(path)
+	assert.NoError(err)
+	assert.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(err)
+	assert.Len(entries, 1, "no temporary file is left behind")
+}
+
+func TestLock(t *testing.T) {
+	assert := require.New(t)
+	path := filepath.Join(t.TempDir(), "store.lock")
+
+	unlock, err := Lock(t.Context(), path)
+	assert.NoError(err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 30*time.Millisecond)
+	defer cancel()
+	_, err = Lock(ctx, path)
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+
+	unlock()
+	unlock, err = Lock(t.Context(), path)
+	assert.NoError(err)
+	unlock()
+}
@@ -0,0 +1,195 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inventory analyzes the snapshots and disks found across dedicated storage contracts.
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+)
+
+const DefaultStuckAfter = time.Hour
+
+// Class is a classification of a snapshot which needs attention.
+type Class string
+
+const (
+	// ClassOrphaned is a snapshot whose source disk no longer exists
+	ClassOrphaned Class = "orphaned"
+	// ClassStuck is a snapshot remaining in a non-terminal state longer than Analyzer.StuckAfter
+	ClassStuck Class = "stuck"
+	// ClassStale is a snapshot older than Analyzer.MaxAge
+	ClassStale Class = "stale"
+	// ClassFailed is a snapshot in the failed state
+	ClassFailed Class = "failed"
+	// ClassOversizedDisk is a snapshot of a disk larger than Analyzer.OversizedDiskMB
+	ClassOversizedDisk Class = "oversized_disk"
+)
+
+var suggestedActions = map[Class]string{
+	ClassOrphaned:      "delete the snapshot if it is no longer needed to recreate the deleted disk",
+	ClassStuck:         "check the snapshot state and contact support if it does not progress",
+	ClassStale:         "review the retention policy and delete the snapshot if it is no longer needed",
+	ClassFailed:        "delete the failed snapshot and take a new one",
+	ClassOversizedDisk: "check that the snapshot pool has enough capacity for snapshots of this disk",
+}
+
+// Finding is a snapshot classified by the analyzer.
+type Finding struct {
+	ContractID   int64               `json:"contract_id"`
+	ContractName string              `json:"contract_name"`
+	SnapshotID   int64               `json:"snapshot_id"`
+	SnapshotName string              `json:"snapshot_name"`
+	State        types.SnapshotState `json:"state"`
+	CreatedAt    time.Time           `json:"created_at"`
+	DiskID       int64               `json:"disk_id"`
+	DiskName     string              `json:"disk_name"`
+	DiskSizeMB   int64               `json:"disk_size_mb"`
+	Classes      []Class             `json:"classes"`
+	Actions      []string            `json:"suggested_actions"`
+}
+
+// Report is the result of Analyzer.Analyze.
+type Report struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Contracts   int       `json:"contracts"`
+	Snapshots   int       `json:"snapshots"`
+	Findings    []Finding `json:"findings"`
+}
+
+// DiskChecker tells whether a disk exists. iaas.Integration is a DiskChecker reading the IaaS API.
+type DiskChecker interface {
+	DiskExists(ctx context.Context, diskID int64) (bool, error)
+}
+
+// Analyzer walks all contracts and classifies their snapshots.
+type Analyzer struct {
+	Contracts dedicatedstorage.ContractAPI
+	// Disks is used to detect orphaned snapshots. Orphans are not detected when nil.
+	// The dedicated storage API has no way to read a disk, so the disks are checked elsewhere,
+	// such as through the IaaS API with iaas.Integration.
+	Disks DiskChecker
+	// DiskReader reads the current state of the disks grouped by GroupByBundle.
+	// The groups are built from the disks recorded in the latest snapshots when nil.
+	DiskReader dedicatedstorage.DiskStateReader
+	// MaxAge is the age after which snapshots are stale. Stale snapshots are not detected when zero.
+	MaxAge time.Duration
+	// StuckAfter is the time after which non-terminal snapshots are stuck. DefaultStuckAfter is used when zero.
+	StuckAfter time.Duration
+	// OversizedDiskMB is the disk size above which snapshots are reported. Not detected when zero.
+	OversizedDiskMB int64
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+}
+
+// Analyze lists the snapshots of every contract and reports the ones which need attention.
+func (a *Analyzer) Analyze(ctx context.Context) (*Report, error) {
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	stuckAfter := a.StuckAfter
+	if stuckAfter <= 0 {
+		stuckAfter = DefaultStuckAfter
+	}
+
+	report := &Report{GeneratedAt: now(), Findings: []Finding{}}
+	contracts, err := a.Contracts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Contracts = len(contracts.DedicatedStorageContracts)
+
+	diskExists := make(map[int64]bool)
+	for _, contract := range contracts.DedicatedStorageContracts {
+		snapshots, err := a.Contracts.ListDiskSnapshots(ctx, contract.ID)
+		if err != nil {
+			return nil, err
+		}
+		report.Snapshots += len(snapshots.DiskSnapshots)
+
+		for _, snapshot := range snapshots.DiskSnapshots {
+			var classes []Class
+
+			if a.Disks != nil {
+				exists, ok := diskExists[snapshot.Disk.ID]
+				if !ok {
+					exists, err = a.Disks.DiskExists(ctx, snapshot.Disk.ID)
+					if err != nil {
+						return nil, fmt.Errorf("checking disk %d: %w", snapshot.Disk.ID, err)
+					}
+					diskExists[snapshot.Disk.ID] = exists
+				}
+				if !exists {
+					classes = append(classes, ClassOrphaned)
+				}
+			}
+
+			age := report.GeneratedAt.Sub(snapshot.CreatedAt)
+			state := types.SnapshotState(snapshot.SnapshotState)
+			switch {
+			case state.IsFailed():
+				classes = append(classes, ClassFailed)
+			case !state.IsTerminal() && age > stuckAfter:
+				classes = append(classes, ClassStuck)
+			}
+			if a.MaxAge > 0 && age > a.MaxAge {
+				classes = append(classes, ClassStale)
+			}
+			if a.OversizedDiskMB > 0 && snapshot.Disk.SizeMB > a.OversizedDiskMB {
+				classes = append(classes, ClassOversizedDisk)
+			}
+
+			if len(classes) == 0 {
+				continue
+			}
+			finding := Finding{
+				ContractID:   contract.ID,
+				ContractName: contract.Name,
+				SnapshotID:   snapshot.ID,
+				SnapshotName: snapshot.Name,
+				State:        state,
+				CreatedAt:    snapshot.CreatedAt,
+				DiskID:       snapshot.Disk.ID,
+				DiskName:     snapshot.Disk.Name,
+				DiskSizeMB:   snapshot.Disk.SizeMB,
+				Classes:      classes,
+			}
+			for _, c := range classes {
+				finding.Actions = append(finding.Actions, suggestedActions[c])
+			}
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+	return report, nil
+}
+
+// FindingsOf returns the findings which have the class.
+func (r *Report) FindingsOf(class Class) []Finding {
+	results := []Finding{}
+	for _, f := range r.Findings {
+		for _, c := range f.Classes {
+			if c == class {
+				results = append(results, f)
+				break
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,116 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/sacloud/saclient-go"
+	"github.com/stretchr/testify/require"
+)
+
+// storeDiskChecker checks the disks of a fake store, whose reader fails with not found for removed disks
+type storeDiskChecker struct {
+	reader dedicatedstorage.DiskStateReader
+}
+
+func (c *storeDiskChecker) DiskExists(ctx context.Context, diskID int64) (bool, error) {
+	_, err := c.reader.ReadDisk(ctx, diskID)
+	if saclient.IsNotFoundError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func TestAnalyzer_Analyze(t *testing.T) {
+	assert := require.New(t)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{Name: "contract"})
+	disk := store.AddDisk(v1.Disk{Name: "disk", SizeMB: 100 * 1024})
+	small := store.AddDisk(v1.Disk{Name: "small", SizeMB: 20 * 1024})
+	deleted := store.AddDisk(v1.Disk{Name: "deleted", SizeMB: 20 * 1024})
+	store.RemoveDisk(deleted.ID)
+
+	snapshot := func(name, state string, createdAt time.Time, d v1.Disk) {
+		store.AddSnapshot(v1.DiskSnapshot{
+			Name:                     name,
+			SnapshotState:            state,
+			CreatedAt:                createdAt,
+			Disk:                     d,
+			DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+		})
+	}
+	snapshot("healthy", "available", now.Add(-time.Hour), small)
+	snapshot("orphan", "available", now.Add(-time.Hour), deleted)
+	snapshot("failed", "failed", now.Add(-time.Hour), disk)
+	snapshot("stuck", "migrating", now.Add(-2*time.Hour), disk)
+	snapshot("old", "available", now.Add(-60*24*time.Hour), disk)
+
+	analyzer := &Analyzer{
+		Contracts:       fake.NewContractOp(store),
+		Disks:           &storeDiskChecker{reader: fake.NewDiskStateReader(store)},
+		MaxAge:          30 * 24 * time.Hour,
+		OversizedDiskMB: 50 * 1024,
+		Now:             func() time.Time { return now },
+	}
+	report, err := analyzer.Analyze(t.Context())
+	assert.NoError(err)
+	assert.Equal(1, report.Contracts)
+	assert.Equal(5, report.Snapshots)
+	assert.Len(report.Findings, 4)
+
+	classes := make(map[string][]Class)
+	for _, f := range report.Findings {
+		classes[f.SnapshotName] = f.Classes
+		assert.Len(f.Actions, len(f.Classes))
+	}
+	assert.Equal(map[string][]Class{
+		"orphan": {ClassOrphaned},
+		"failed": {ClassFailed, ClassOversizedDisk},
+		"stuck":  {ClassStuck, ClassOversizedDisk},
+		"old":    {ClassStale, ClassOversizedDisk},
+	}, classes)
+	assert.Len(report.FindingsOf(ClassOversizedDisk), 3)
+
+	var buf bytes.Buffer
+	assert.NoError(report.Write(&buf, FormatCSV))
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(err)
+	assert.Len(records, 5)
+	assert.Equal(reportHeader, records[0])
+
+	buf.Reset()
+	assert.NoError(report.Write(&buf, FormatJSON))
+	var decoded Report
+	assert.NoError(json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Len(decoded.Findings, 4)
+
+	buf.Reset()
+	assert.NoError(report.Write(&buf, FormatTable))
+	assert.True(strings.HasPrefix(buf.String(), "CONTRACT_ID"))
+
+	assert.Error(report.Write(&buf, "xml"))
+}
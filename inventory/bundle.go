@@ -0,0 +1,178 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	"github.com/sacloud/saclient-go"
+)
+
+// BundleDisk is a disk seen in the snapshots of the contracts. Its name, size and bundle are the
+// current ones when read through Analyzer.DiskReader, those of its latest snapshot otherwise.
+type BundleDisk struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	SizeMB int64  `json:"size_mb"`
+	// ContractID is the contract holding the latest snapshot of the disk
+	ContractID       int64     `json:"contract_id"`
+	LatestSnapshotAt time.Time `json:"latest_snapshot_at"`
+	Snapshots        int       `json:"snapshots"`
+}
+
+// BundleGroup is the disks on the same dedicated host bundle and host class.
+type BundleGroup struct {
+	// BundleID is zero for the disks which are not on a bundle
+	BundleID  int64        `json:"bundle_id"`
+	HostClass string       `json:"host_class"`
+	Disks     []BundleDisk `json:"disks"`
+}
+
+// DiskIDs returns the IDs of the disks in the group.
+func (g *BundleGroup) DiskIDs() []int64 {
+	ids := make([]int64, 0, len(g.Disks))
+	for _, disk := range g.Disks {
+		ids = append(ids, disk.ID)
+	}
+	return ids
+}
+
+// Snapshot takes a group snapshot of all disks in the group into the contract.
+// The group should come from a BundleInventory whose Current is true, as historical
+// groups may name deleted disks or miss disks moved to the bundle since their last snapshot.
+func (g *BundleGroup) Snapshot(ctx context.Context, api dedicatedstorage.SnapshotGroupAPI, contractID int64, name string) (*dedicatedstorage.GroupSnapshot, error) {
+	return api.CreateGroupSnapshot(ctx, contractID, g.DiskIDs(), name)
+}
+
+// BundleInventory is the result of Analyzer.GroupByBundle.
+type BundleInventory struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// Current reports whether the disks were read through Analyzer.DiskReader. Otherwise the groups are
+	// historical: they place each disk where its latest snapshot saw it, and include deleted disks.
+	Current bool `json:"current"`
+	// Groups are ordered by bundle ID and host class, the disks of each group by ID
+	Groups []BundleGroup `json:"groups"`
+}
+
+// Bundle returns the groups of the bundle, one per host class.
+func (inv *BundleInventory) Bundle(bundleID int64) []BundleGroup {
+	groups := []BundleGroup{}
+	for _, g := range inv.Groups {
+		if g.BundleID == bundleID {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// DiskIDs returns the IDs of all disks on the bundle regardless of their host class.
+func (inv *BundleInventory) DiskIDs(bundleID int64) []int64 {
+	ids := []int64{}
+	for _, g := range inv.Bundle(bundleID) {
+		ids = append(ids, g.DiskIDs()...)
+	}
+	return ids
+}
+
+type bundleKey struct {
+	bundleID  int64
+	hostClass string
+}
+
+// GroupByBundle lists the snapshots of every contract and groups the disks they were taken from
+// by bundle and host class. Disks without snapshots are not found.
+//
+// The disks are read through DiskReader when set, which leaves out the deleted disks and places the
+// others on their current bundle. Otherwise the bundle of a disk is read from its latest snapshot,
+// see BundleInventory.Current.
+func (a *Analyzer) GroupByBundle(ctx context.Context) (*BundleInventory, error) {
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+
+	contracts, err := a.Contracts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	disks := make(map[int64]*BundleDisk)
+	keys := make(map[int64]bundleKey)
+	for _, contract := range contracts.DedicatedStorageContracts {
+		snapshots, err := a.Contracts.ListDiskSnapshots(ctx, contract.ID)
+		if err != nil {
+			return nil, fmt.Errorf("listing snapshots of contract %d: %w", contract.ID, err)
+		}
+		for _, snapshot := range snapshots.DiskSnapshots {
+			disk, ok := disks[snapshot.Disk.ID]
+			if !ok {
+				disk = &BundleDisk{ID: snapshot.Disk.ID}
+				disks[disk.ID] = disk
+			}
+			disk.Snapshots++
+			if ok && !snapshot.CreatedAt.After(disk.LatestSnapshotAt) {
+				continue
+			}
+			disk.Name = snapshot.Disk.Name
+			disk.SizeMB = snapshot.Disk.SizeMB
+			disk.ContractID = contract.ID
+			disk.LatestSnapshotAt = snapshot.CreatedAt
+			keys[disk.ID] = bundleKey{
+				bundleID:  dedicatedstorage.DiskBundleID(&snapshot.Disk),
+				hostClass: dedicatedstorage.DiskHostClass(&snapshot.Disk),
+			}
+		}
+	}
+
+	if a.DiskReader != nil {
+		for id, disk := range disks {
+			current, err := a.DiskReader.ReadDisk(ctx, id)
+			if saclient.IsNotFoundError(err) {
+				delete(disks, id)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading disk %d: %w", id, err)
+			}
+			disk.Name = current.Name
+			disk.SizeMB = current.SizeMB
+			keys[id] = bundleKey{
+				bundleID:  dedicatedstorage.DiskBundleID(current),
+				hostClass: dedicatedstorage.DiskHostClass(current),
+			}
+		}
+	}
+
+	grouped := make(map[bundleKey][]BundleDisk)
+	for id, disk := range disks {
+		grouped[keys[id]] = append(grouped[keys[id]], *disk)
+	}
+	inv := &BundleInventory{GeneratedAt: now(), Current: a.DiskReader != nil, Groups: []BundleGroup{}}
+	for key, disks := range grouped {
+		sort.Slice(disks, func(i, j int) bool { return disks[i].ID < disks[j].ID })
+		inv.Groups = append(inv.Groups, BundleGroup{BundleID: key.bundleID, HostClass: key.hostClass, Disks: disks})
+	}
+	sort.Slice(inv.Groups, func(i, j int) bool {
+		if inv.Groups[i].BundleID != inv.Groups[j].BundleID {
+			return inv.Groups[i].BundleID < inv.Groups[j].BundleID
+		}
+		return inv.Groups[i].HostClass < inv.Groups[j].HostClass
+	})
+	return inv, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzer_GroupByBundle(t *testing.T) {
+	assert := require.New(t)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	store := fake.NewStore()
+	first := store.AddContract(v1.DedicatedStorageContract{Name: "first"})
+	second := store.AddContract(v1.DedicatedStorageContract{Name: "second"})
+
+	bundled := func(name string, bundleID int64, hostClass string) v1.Disk {
+		return store.AddDisk(v1.Disk{
+			Name:       name,
+			BundleInfo: v1.NewNilDiskBundleInfo(v1.DiskBundleInfo{ID: v1.NewNilInt64(bundleID), HostClass: v1.NewNilString(hostClass)}),
+		})
+	}
+	web := bundled("web", 10, "dynamic")
+	db := bundled("db", 10, "dynamic")
+	batch := bundled("batch", 10, "static")
+	other := bundled("other", 20, "dynamic")
+	plain := store.AddDisk(v1.Disk{Name: "plain"})
+	plain.BundleInfo.SetToNull()
+
+	snapshot := func(contractID int64, disk v1.Disk, createdAt time.Time) {
+		store.AddSnapshot(v1.DiskSnapshot{
+			SnapshotState:            "available",
+			CreatedAt:                createdAt,
+			Disk:                     disk,
+			DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contractID},
+		})
+	}
+	snapshot(first.ID, web, now.Add(-2*time.Hour))
+	snapshot(second.ID, web, now.Add(-time.Hour))
+	snapshot(first.ID, db, now.Add(-time.Hour))
+	snapshot(first.ID, batch, now.Add(-time.Hour))
+	snapshot(second.ID, other, now.Add(-time.Hour))
+	snapshot(second.ID, plain, now.Add(-time.Hour))
+
+	analyzer := &Analyzer{Contracts: fake.NewContractOp(store), Now: func() time.Time { return now }}
+	inv, err := analyzer.GroupByBundle(t.Context())
+	assert.NoError(err)
+
+	assert.Len(inv.Groups, 4)
+	assert.EqualValues(0, inv.Groups[0].BundleID)
+	assert.Equal([]int64{plain.ID}, inv.Groups[0].DiskIDs())
+	assert.Equal("dynamic", inv.Groups[1].HostClass)
+	assert.ElementsMatch([]int64{web.ID, db.ID}, inv.Groups[1].DiskIDs())
+	assert.ElementsMatch([]int64{web.ID, db.ID, batch.ID}, inv.DiskIDs(10))
+	assert.Len(inv.Bundle(20), 1)
+
+	for _, disk := range inv.Groups[1].Disks {
+		if disk.ID == web.ID {
+			assert.Equal(2, disk.Snapshots)
+			assert.Equal(second.ID, disk.ContractID)
+		}
+	}
+
+	disks := fake.NewDiskOp(store)
+	groupOp := dedicatedstorage.NewSnapshotGroupOp(disks, &dedicatedstorage.SnapshotWaiter{API: disks, Interval: time.Millisecond})
+	group, err := inv.Groups[1].Snapshot(t.Context(), groupOp, first.ID, "bundle-10")
+	assert.NoError(err)
+	assert.Len(group.Snapshots, 2)
+	assert.False(inv.Current)
+
+	t.Run("current disks", func(t *testing.T) {
+		assert := require.New(t)
+		// db moved to bundle 20 and batch was deleted since their latest snapshots
+		db.BundleInfo.Value.ID = v1.NewNilInt64(20)
+		store.AddDisk(db)
+		store.RemoveDisk(batch.ID)
+
+		analyzer := &Analyzer{Contracts: fake.NewContractOp(store), DiskReader: fake.NewDiskStateReader(store)}
+		inv, err := analyzer.GroupByBundle(t.Context())
+		assert.NoError(err)
+		assert.True(inv.Current)
+		assert.Equal([]int64{web.ID}, inv.DiskIDs(10))
+		assert.ElementsMatch([]int64{db.ID, other.ID}, inv.DiskIDs(20))
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Format is an output format of reports.
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatCSV   Format = "csv"
+	FormatJSON  Format = "json"
+)
+
+// Write writes the report in the format.
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatTable, "":
+		return r.WriteTable(w)
+	case FormatCSV:
+		return r.WriteCSV(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	}
+	return fmt.Errorf("unsupported format: %q", format)
+}
+
+var reportHeader = []string{
+	"CONTRACT_ID", "CONTRACT", "SNAPSHOT_ID", "SNAPSHOT", "STATE", "CREATED_AT",
+	"DISK_ID", "DISK", "DISK_SIZE_MB", "CLASSES", "SUGGESTED_ACTIONS",
+}
+
+func (f *Finding) columns() []string {
+	classes := make([]string, len(f.Classes))
+	for i, c := range f.Classes {
+		classes[i] = string(c)
+	}
+	return []string{
+		strconv.FormatInt(f.ContractID, 10),
+		f.ContractName,
+		strconv.FormatInt(f.SnapshotID, 10),
+		f.SnapshotName,
+		f.State.String(),
+		f.CreatedAt.Format(time.RFC3339),
+		strconv.FormatInt(f.DiskID, 10),
+		f.DiskName,
+		strconv.FormatInt(f.DiskSizeMB, 10),
+		strings.Join(classes, ","),
+		strings.Join(f.Actions, "; "),
+	}
+}
+
+// WriteTable writes the findings as a human-readable table.
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(reportHeader, "\t")) //nolint:errcheck
+	for i := range r.Findings {
+		fmt.Fprintln(tw, strings.Join(r.Findings[i].columns(), "\t")) //nolint:errcheck
+	}
+	return tw.Flush()
+}
+
+// WriteCSV writes the findings as CSV with a header row.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(reportHeader); err != nil {
+		return err
+	}
+	for i := range r.Findings {
+		if err := cw.Write(r.Findings[i].columns()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the whole report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,95 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package job runs long DiskAPI operations as persisted jobs, so that a restarted
+// worker resumes waiting for operations submitted before the restart.
+package job
+
+import (
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+)
+
+// State is the state of a job.
+//
+// Jobs move from StateQueued to StateSubmitting right before the operation is sent to the API,
+// to StateSubmitted once the API accepted it, then to StateWaiting while polling for its completion,
+// and end in StateDone or StateFailed. A job resumed in StateSubmitting checks the disk before
+// sending the operation again.
+type State string
+
+const (
+	StateQueued     State = "queued"
+	StateSubmitting State = "submitting"
+	StateSubmitted  State = "submitted"
+	StateWaiting    State = "waiting"
+	StateDone       State = "done"
+	StateFailed     State = "failed"
+)
+
+// IsTerminal reports whether the job has finished.
+func (s State) IsTerminal() bool {
+	return s == StateDone || s == StateFailed
+}
+
+// Kind is the operation a job runs.
+type Kind string
+
+const (
+	// KindSnapshot creates a snapshot and waits until it becomes available
+	KindSnapshot Kind = "snapshot"
+	// KindRestore restores a disk from a snapshot and waits until the disk becomes available
+	KindRestore Kind = "restore"
+	// KindExpand expands a disk and waits until the disk becomes available
+	KindExpand Kind = "expand"
+)
+
+// Spec is the operation of a job.
+type Spec struct {
+	Kind   Kind  `json:"kind"`
+	DiskID int64 `json:"disk_id"`
+
+	// ContractID, Name, Description and Metadata are the snapshot to create, for KindSnapshot.
+	// The job ID is added to the metadata as dedicatedstorage.MetadataKeyJobID, so that a resumed
+	// job finds the snapshot it created.
+	ContractID  int64                             `json:"contract_id,omitempty"`
+	Name        string                            `json:"name,omitempty"`
+	Description string                            `json:"description,omitempty"`
+	Metadata    dedicatedstorage.SnapshotMetadata `json:"metadata,omitempty"`
+
+	// SnapshotID is the snapshot to restore from, for KindRestore
+	SnapshotID int64 `json:"snapshot_id,omitempty"`
+
+	// SizeGiB is the size to expand the disk to, for KindExpand
+	SizeGiB int64 `json:"size_gib,omitempty"`
+}
+
+// Job is a persisted operation.
+type Job struct {
+	ID    string `json:"id"`
+	Spec  Spec   `json:"spec"`
+	State State  `json:"state"`
+	// ResultSnapshotID is the snapshot created by a KindSnapshot job, set once submitted
+	ResultSnapshotID int64 `json:"result_snapshot_id,omitempty"`
+	// Error is the reason of StateFailed
+	Error string `json:"error,omitempty"`
+	// Attempts is the number of times the job was started, including resumes
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// SubmittedAt is when the operation was sent to the API
+	SubmittedAt time.Time `json:"submitted_at,omitzero"`
+	FinishedAt  time.Time `json:"finished_at,omitzero"`
+}
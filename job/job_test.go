@@ -0,0 +1,363 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	store    *fake.Store
+	contract v1.DedicatedStorageContract
+	disk     v1.Disk
+	snapshot v1.DiskSnapshot
+	path     string
+}
+
+func newFixture(t *testing.T) *fixture {
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	disk := store.AddDisk(v1.Disk{SizeMB: 20 * 1024, Availability: "available"})
+	snapshot := store.AddSnapshot(v1.DiskSnapshot{
+		Name:                     "base",
+		SnapshotState:            "available",
+		CreatedAt:                time.Now().Add(-time.Hour),
+		Disk:                     disk,
+		DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+	})
+	return &fixture{
+		store:    store,
+		contract: contract,
+		disk:     disk,
+		snapshot: snapshot,
+		path:     filepath.Join(t.TempDir(), "jobs.json"),
+	}
+}
+
+func (f *fixture) runner() *Runner {
+	runner := NewRunner(NewFileStore(f.path), fake.NewDiskOp(f.store))
+	runner.StateReader = fake.NewDiskStateReader(f.store)
+	runner.WaitInterval = 5 * time.Millisecond
+	runner.WaitStartTimeout = 100 * time.Millisecond
+	runner.WaitTimeout = time.Second
+	return runner
+}
+
+func TestRunner_Run(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+	f := newFixture(t)
+	f.store.OperationStates = []types.Availability{types.AvailabilityMigrating}
+	runner := f.runner()
+
+	snapshotJob, err := runner.Submit(ctx, &Spec{Kind: KindSnapshot, DiskID: f.disk.ID, ContractID: f.contract.ID, Name: "daily"})
+	assert.NoError(err)
+	restoreJob, err := runner.Submit(ctx, &Spec{Kind: KindRestore, DiskID: f.disk.ID, SnapshotID: f.snapshot.ID})
+	assert.NoError(err)
+	f.store.Fail("Disk.Expand", errors.New("expand failed"))
+	expandJob, err := runner.Submit(ctx, &Spec{Kind: KindExpand, DiskID: f.disk.ID, SizeGiB: 40})
+	assert.NoError(err)
+
+	finished, err := runner.Run(ctx)
+	assert.NoError(err)
+	assert.Equal([]string{snapshotJob.ID, restoreJob.ID, expandJob.ID},
+		[]string{finished[0].ID, finished[1].ID, finished[2].ID})
+	assert.Equal(StateFailed, finished[2].State)
+
+	jobs, err := runner.List(ctx)
+	assert.NoError(err)
+	assert.Len(jobs, 3)
+
+	snapshotJob, err = runner.Get(ctx, snapshotJob.ID)
+	assert.NoError(err)
+	assert.Equal(StateDone, snapshotJob.State)
+	assert.NotZero(snapshotJob.ResultSnapshotID)
+	assert.False(snapshotJob.FinishedAt.IsZero())
+
+	restoreJob, err = runner.Get(ctx, restoreJob.ID)
+	assert.NoError(err)
+	assert.Equal(StateDone, restoreJob.State)
+
+	expandJob, err = runner.Get(ctx, expandJob.ID)
+	assert.NoError(err)
+	assert.Equal(StateFailed, expandJob.State)
+	assert.Contains(expandJob.Error, "expand failed")
+
+	// jobs finished by earlier runs are not reported again
+	finished, err = runner.Run(ctx)
+	assert.NoError(err)
+	assert.Empty(finished)
+}
+
+func TestRunner_Resume(t *testing.T) {
+	assert := require.New(t)
+	f := newFixture(t)
+	f.store.InitialSnapshotState = types.SnapshotStateCreating
+
+	job, err := f.runner().Submit(t.Context(), &Spec{Kind: KindSnapshot, DiskID: f.disk.ID, ContractID: f.contract.ID, Name: "daily"})
+	assert.NoError(err)
+
+	// the worker is stopped while waiting
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	finished, err := f.runner().Run(ctx)
+	assert.Error(err)
+	assert.Empty(finished)
+	job, err = NewFileStore(f.path).Get(t.Context(), job.ID)
+	assert.NoError(err)
+	assert.Equal(StateWaiting, job.State)
+
+	// a new worker resumes polling without creating the snapshot again
+	f.store.SetSnapshotState(job.ResultSnapshotID, types.SnapshotStateAvailable)
+	finished, err = f.runner().Run(t.Context())
+	assert.NoError(err)
+	assert.Len(finished, 1)
+	job, err = NewFileStore(f.path).Get(t.Context(), job.ID)
+	assert.NoError(err)
+	assert.Equal(StateDone, job.State)
+	assert.Equal(2, job.Attempts)
+	assert.Len(f.store.Snapshots(), 2)
+}
+
+func TestRunner_SnapshotJobID(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+	f := newFixture(t)
+	runner := f.runner()
+
+	// jobs of the same name each create their snapshot
+	for range 2 {
+		_, err := runner.Submit(ctx, &Spec{Kind: KindSnapshot, DiskID: f.disk.ID, ContractID: f.contract.ID, Name: "daily"})
+		assert.NoError(err)
+	}
+	finished, err := runner.Run(ctx)
+	assert.NoError(err)
+	assert.Len(finished, 2)
+	assert.NotEqual(finished[0].ResultSnapshotID, finished[1].ResultSnapshotID)
+
+	for _, job := range finished {
+		snapshot, ok := f.store.Snapshot(job.ResultSnapshotID)
+		assert.True(ok)
+		metadata, err := dedicatedstorage.SnapshotMetadataOf(&snapshot)
+		assert.NoError(err)
+		assert.Equal(job.ID, metadata[dedicatedstorage.MetadataKeyJobID])
+	}
+
+	// a job whose snapshot was created before the restart is not created again
+	snapshots := len(f.store.Snapshots())
+	created := f.store.AddSnapshot(v1.DiskSnapshot{
+		Name:                     "daily",
+		Description:              mustDescription(t, dedicatedstorage.SnapshotMetadata{dedicatedstorage.MetadataKeyJobID: "crashed"}),
+		SnapshotState:            "available",
+		Disk:                     f.disk,
+		DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: f.contract.ID},
+	})
+	now := time.Now()
+	assert.NoError(runner.Store.Create(ctx, &Job{
+		ID:        "crashed",
+		Spec:      Spec{Kind: KindSnapshot, DiskID: f.disk.ID, ContractID: f.contract.ID, Name: "daily"},
+		State:     StateQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}))
+	finished, err = runner.Run(ctx)
+	assert.NoError(err)
+	assert.Len(finished, 1)
+	assert.Equal(StateDone, finished[0].State, finished[0].Error)
+	assert.Equal(created.ID, finished[0].ResultSnapshotID)
+	assert.Len(f.store.Snapshots(), snapshots+1)
+}
+
+func mustDescription(t *testing.T, metadata dedicatedstorage.SnapshotMetadata) string {
+	description, err := dedicatedstorage.EncodeSnapshotDescription("", metadata)
+	require.NoError(t, err)
+	return description
+}
+
+func TestRunner_WaitForOperation(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+	f := newFixture(t)
+	// the disk stays available for one poll after the operation is accepted
+	f.store.OperationStates = []types.Availability{types.AvailabilityAvailable, types.AvailabilityMigrating}
+	runner := f.runner()
+	reader := &recordingReader{DiskStateReader: runner.StateReader}
+	runner.StateReader = reader
+
+	_, err := runner.Submit(ctx, &Spec{Kind: KindRestore, DiskID: f.disk.ID, SnapshotID: f.snapshot.ID})
+	assert.NoError(err)
+	_, err = runner.Submit(ctx, &Spec{Kind: KindExpand, DiskID: f.disk.ID, SizeGiB: 40})
+	assert.NoError(err)
+
+	finished, err := runner.Run(ctx)
+	assert.NoError(err)
+	for _, job := range finished {
+		assert.Equal(StateDone, job.State, job.Error)
+	}
+	transition := []types.Availability{types.AvailabilityAvailable, types.AvailabilityMigrating, types.AvailabilityAvailable}
+	assert.Equal(append(slices.Clone(transition), transition...), reader.seen)
+
+	t.Run("operation not observed", func(t *testing.T) {
+		assert := require.New(t)
+		f.store.OperationStates = nil
+		job, err := runner.Submit(ctx, &Spec{Kind: KindRestore, DiskID: f.disk.ID, SnapshotID: f.snapshot.ID})
+		assert.NoError(err)
+
+		finished, err := runner.Run(ctx)
+		assert.NoError(err)
+		assert.Len(finished, 1)
+		assert.Equal(job.ID, finished[0].ID)
+		assert.Equal(StateFailed, finished[0].State)
+		assert.Contains(finished[0].Error, dedicatedstorage.ErrDiskOperationNotObserved.Error())
+	})
+
+	t.Run("resumed long after submission", func(t *testing.T) {
+		assert := require.New(t)
+		submittedAt := time.Now().Add(-time.Hour)
+		job := &Job{
+			ID:          "resumed",
+			Spec:        Spec{Kind: KindRestore, DiskID: f.disk.ID, SnapshotID: f.snapshot.ID},
+			State:       StateWaiting,
+			CreatedAt:   submittedAt,
+			UpdatedAt:   submittedAt,
+			SubmittedAt: submittedAt,
+		}
+		assert.NoError(runner.Store.Create(ctx, job))
+
+		finished, err := runner.Run(ctx)
+		assert.NoError(err)
+		assert.Len(finished, 1)
+		assert.Equal(StateDone, finished[0].State, finished[0].Error)
+	})
+}
+
+func TestRunner_ResumeSubmitting(t *testing.T) {
+	ctx := t.Context()
+	submitting := func(f *fixture, id string, spec Spec) {
+		now := time.Now()
+		job := &Job{ID: id, Spec: spec, State: StateSubmitting, CreatedAt: now, UpdatedAt: now, SubmittedAt: now}
+		require.NoError(t, NewFileStore(f.path).Create(ctx, job))
+	}
+	count := func(calls []string, method string) int {
+		n := 0
+		for _, call := range calls {
+			if call == method {
+				n++
+			}
+		}
+		return n
+	}
+
+	t.Run("sent before the restart", func(t *testing.T) {
+		assert := require.New(t)
+		f := newFixture(t)
+		f.store.OperationStates = []types.Availability{types.AvailabilityMigrating, types.AvailabilityMigrating}
+		assert.NoError(fake.NewDiskOp(f.store).Expand(ctx, f.disk.ID, &v1.ExpandDiskRequest{ExpanedSizeMB: 40 * 1024}))
+		submitting(f, "expand", Spec{Kind: KindExpand, DiskID: f.disk.ID, SizeGiB: 40})
+
+		finished, err := f.runner().Run(ctx)
+		assert.NoError(err)
+		assert.Len(finished, 1)
+		assert.Equal(StateDone, finished[0].State, finished[0].Error)
+		assert.Equal(1, count(f.store.Calls(), "Disk.Expand"), "the expansion is not sent again")
+	})
+
+	t.Run("not sent before the restart", func(t *testing.T) {
+		assert := require.New(t)
+		f := newFixture(t)
+		f.store.OperationStates = []types.Availability{types.AvailabilityMigrating}
+		submitting(f, "expand", Spec{Kind: KindExpand, DiskID: f.disk.ID, SizeGiB: 40})
+
+		finished, err := f.runner().Run(ctx)
+		assert.NoError(err)
+		assert.Len(finished, 1)
+		assert.Equal(StateDone, finished[0].State, finished[0].Error)
+		assert.Equal(1, count(f.store.Calls(), "Disk.Expand"))
+	})
+
+	t.Run("unknown restore", func(t *testing.T) {
+		assert := require.New(t)
+		f := newFixture(t)
+		submitting(f, "restore", Spec{Kind: KindRestore, DiskID: f.disk.ID, SnapshotID: f.snapshot.ID})
+
+		finished, err := f.runner().Run(ctx)
+		assert.NoError(err)
+		assert.Len(finished, 1)
+		assert.Equal(StateFailed, finished[0].State)
+		assert.Contains(finished[0].Error, ErrUnknownSubmission.Error())
+		assert.Zero(count(f.store.Calls(), "Disk.RestoreFromSnapshot"), "the restore is not sent again")
+	})
+}
+
+// recordingReader records the availability of every disk read
+type recordingReader struct {
+	dedicatedstorage.DiskStateReader
+	seen []types.Availability
+}
+
+func (r *recordingReader) ReadDisk(ctx context.Context, diskID int64) (*v1.Disk, error) {
+	disk, err := r.DiskStateReader.ReadDisk(ctx, diskID)
+	if err == nil {
+		r.seen = append(r.seen, types.Availability(disk.Availability))
+	}
+	return disk, err
+}
+
+func TestRunner_Submit(t *testing.T) {
+	assert := require.New(t)
+	f := newFixture(t)
+	runner := f.runner()
+
+	_, err := runner.Submit(t.Context(), &Spec{Kind: KindExpand, DiskID: f.disk.ID, SizeGiB: 0})
+	assert.Error(err)
+	_, err = runner.Submit(t.Context(), &Spec{Kind: KindRestore, DiskID: f.disk.ID})
+	assert.Error(err)
+	_, err = runner.Submit(t.Context(), &Spec{Kind: "unknown", DiskID: f.disk.ID})
+	assert.Error(err)
+	_, err = runner.Submit(t.Context(), &Spec{
+		Kind: KindSnapshot, DiskID: f.disk.ID, ContractID: f.contract.ID, Name: "daily",
+		Metadata: dedicatedstorage.SnapshotMetadata{dedicatedstorage.MetadataKeyJobID: "other"},
+	})
+	assert.ErrorContains(err, "reserved")
+
+	t.Run("no state reader", func(t *testing.T) {
+		assert := require.New(t)
+		runner := f.runner()
+		runner.StateReader = nil
+		_, err := runner.Submit(t.Context(), &Spec{Kind: KindExpand, DiskID: f.disk.ID, SizeGiB: 40})
+		assert.NoError(err)
+
+		finished, err := runner.Run(t.Context())
+		assert.NoError(err)
+		assert.Len(finished, 1)
+		assert.Equal(StateFailed, finished[0].State)
+		assert.Contains(finished[0].Error, dedicatedstorage.ErrNoDiskStateReader.Error())
+		assert.NotContains(f.store.Calls(), "Disk.Expand")
+	})
+
+	_, err = runner.Get(t.Context(), "missing")
+	assert.True(errors.Is(err, ErrNotFound))
+}
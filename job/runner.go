@@ -0,0 +1,435 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+)
+
+// ErrUnknownSubmission is returned for a restore job interrupted while it was submitted,
+// when the disk does not tell whether the restore was sent. It is not sent again.
+var ErrUnknownSubmission = errors.New("the operation may have been submitted before the restart")
+
+// DefaultConcurrency is the number of jobs run at the same time when Runner.Concurrency is zero.
+const DefaultConcurrency = 4
+
+// Runner submits jobs to DiskAPI and waits for their completion.
+//
+// Jobs of the same disk run one at a time in the order they were submitted.
+// A store must be run by a single Runner at a time.
+type Runner struct {
+	Store Store
+	Disk  dedicatedstorage.DiskAPI
+	// StateReader reads disks while waiting for restores and expansions. It must follow the disk
+	// itself, such as iaas.Integration: restore and expand jobs fail with
+	// dedicatedstorage.ErrNoDiskStateReader without calling the API when nil.
+	StateReader dedicatedstorage.DiskStateReader
+	// Concurrency is the maximum number of jobs run at the same time. DefaultConcurrency is used when zero.
+	Concurrency int
+	// WaitInterval is the polling interval while waiting. The defaults of the root package are used when zero.
+	WaitInterval time.Duration
+	// WaitStartTimeout is the maximum time to wait for a disk to leave the available state once a restore
+	// or an expansion is accepted. DefaultDiskStartTimeout of the root package is used when zero.
+	WaitStartTimeout time.Duration
+	// WaitTimeout is the maximum time to wait for a job. The defaults of the root package are used when zero.
+	WaitTimeout time.Duration
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+}
+
+// NewRunner returns a Runner with the default settings.
+func NewRunner(store Store, disk dedicatedstorage.DiskAPI) *Runner {
+	return &Runner{Store: store, Disk: disk}
+}
+
+func (r *Runner) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// Submit validates spec and records it as a queued job. It is run by the next Run.
+func (r *Runner) Submit(ctx context.Context, spec *Spec) (*Job, error) {
+	const methodName = "Job.Submit"
+
+	if err := validateSpec(spec); err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+	id, err := newJobID()
+	if err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+	now := r.now()
+	job := &Job{ID: id, Spec: *spec, State: StateQueued, CreatedAt: now, UpdatedAt: now}
+	if err := r.Store.Create(ctx, job); err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+	return job, nil
+}
+
+// Get returns the job.
+func (r *Runner) Get(ctx context.Context, id string) (*Job, error) {
+	return r.Store.Get(ctx, id)
+}
+
+// List returns all jobs in the order they were submitted.
+func (r *Runner) List(ctx context.Context) ([]*Job, error) {
+	return r.Store.List(ctx)
+}
+
+// Run runs all unfinished jobs until they are done or failed. Jobs submitted or waiting
+// before a restart are resumed by polling again instead of being submitted twice.
+//
+// Run returns the jobs it finished, done or failed, in the order they were submitted. Jobs which
+// were already finished before are not returned. Failures of jobs are recorded in the jobs.
+// Run returns an error only when the store fails or ctx is done, leaving the unfinished jobs
+// to be resumed by the next Run.
+func (r *Runner) Run(ctx context.Context) ([]*Job, error) {
+	jobs, err := r.Store.List(ctx)
+	if err != nil {
+		return nil, dedicatedstorage.NewError("Job.Run", err)
+	}
+
+	byDisk := make(map[int64][]*Job)
+	var disks []int64
+	for _, job := range jobs {
+		if job.State.IsTerminal() {
+			continue
+		}
+		if _, ok := byDisk[job.Spec.DiskID]; !ok {
+			disks = append(disks, job.Spec.DiskID)
+		}
+		byDisk[job.Spec.DiskID] = append(byDisk[job.Spec.DiskID], job)
+	}
+
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		errs     []error
+		finished = make(map[string]*Job)
+	)
+	for _, diskID := range disks {
+		wg.Add(1)
+		go func(jobs []*Job) {
+			defer wg.Done()
+			for _, job := range jobs {
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				result, err := r.run(ctx, job)
+				<-sem
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+					mu.Unlock()
+					return
+				}
+				finished[job.ID] = result
+				mu.Unlock()
+			}
+		}(byDisk[diskID])
+	}
+	wg.Wait()
+
+	var results []*Job
+	for _, job := range jobs {
+		if result, ok := finished[job.ID]; ok {
+			results = append(results, result)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return results, errors.Join(errs...)
+}
+
+// run drives the job to a terminal state and returns it. It returns an error only when the job
+// must be resumed later.
+func (r *Runner) run(ctx context.Context, job *Job) (*Job, error) {
+	const methodName = "Job.Run"
+
+	job, err := r.Store.Update(ctx, job.ID, func(job *Job) error {
+		job.Attempts++
+		job.UpdatedAt = r.now()
+		return nil
+	})
+	if err != nil {
+		return nil, dedicatedstorage.NewError(methodName, err)
+	}
+
+	if job.Spec.Kind != KindSnapshot && r.StateReader == nil {
+		return r.finish(ctx, job.ID, dedicatedstorage.NewError(methodName, fmt.Errorf("%w: %s jobs wait for the disk", dedicatedstorage.ErrNoDiskStateReader, job.Spec.Kind)))
+	}
+
+	resumed := job.State != StateQueued
+	if job.State == StateQueued {
+		// recorded before calling the API, so that a restart never sends the operation blindly again
+		job, err = r.Store.Update(ctx, job.ID, func(job *Job) error {
+			job.State = StateSubmitting
+			job.SubmittedAt = r.now()
+			job.UpdatedAt = job.SubmittedAt
+			return nil
+		})
+		if err != nil {
+			return nil, dedicatedstorage.NewError(methodName, err)
+		}
+	}
+
+	if job.State == StateSubmitting {
+		snapshotID, sent, err := r.submit(ctx, job, resumed)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return r.finish(ctx, job.ID, err)
+		}
+		job, err = r.Store.Update(ctx, job.ID, func(job *Job) error {
+			job.State = StateSubmitted
+			job.ResultSnapshotID = snapshotID
+			job.UpdatedAt = r.now()
+			if sent {
+				job.SubmittedAt = job.UpdatedAt
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, dedicatedstorage.NewError(methodName, err)
+		}
+		if sent {
+			resumed = false
+		}
+	}
+
+	if job.State == StateSubmitted {
+		job, err = r.Store.Update(ctx, job.ID, func(job *Job) error {
+			job.State = StateWaiting
+			job.UpdatedAt = r.now()
+			return nil
+		})
+		if err != nil {
+			return nil, dedicatedstorage.NewError(methodName, err)
+		}
+	}
+
+	err = r.wait(ctx, job, resumed)
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return r.finish(ctx, job.ID, err)
+}
+
+// submit calls the API for the job and returns the created snapshot of KindSnapshot jobs.
+// A job resumed in StateSubmitting may have sent its operation before the restart: sent reports
+// false when it is found on the disk instead of being sent again.
+func (r *Runner) submit(ctx context.Context, job *Job, resumed bool) (snapshotID int64, sent bool, err error) {
+	spec := &job.Spec
+	if spec.Kind == KindSnapshot {
+		// a previous attempt may have created the snapshot without recording it
+		snapshot, err := r.findSnapshot(ctx, job)
+		if err != nil {
+			return 0, false, err
+		}
+		if snapshot != nil {
+			return snapshot.ID, false, nil
+		}
+		request, err := spec.snapshotParams(job.ID).Request()
+		if err != nil {
+			return 0, false, err
+		}
+		created, err := r.Disk.CreateSnapshot(ctx, spec.DiskID, request)
+		if err != nil {
+			return 0, false, err
+		}
+		return created.ID, true, nil
+	}
+
+	if resumed {
+		found, err := r.findOperation(ctx, job)
+		if err != nil || found {
+			return 0, false, err
+		}
+	}
+	switch spec.Kind {
+	case KindRestore:
+		return 0, true, r.Disk.RestoreFromSnapshot(ctx, spec.DiskID, spec.SnapshotID)
+	case KindExpand:
+		return 0, true, r.Disk.Expand(ctx, spec.DiskID, (&dedicatedstorage.ExpandParams{SizeGiB: spec.SizeGiB}).Request())
+	default:
+		return 0, false, fmt.Errorf("unknown job kind %q", spec.Kind)
+	}
+}
+
+// findOperation reports whether the restore or the expansion of the job reached the disk, running or applied.
+// A restore of an available disk fails with ErrUnknownSubmission, as it may have completed already.
+func (r *Runner) findOperation(ctx context.Context, job *Job) (bool, error) {
+	disk, err := r.StateReader.ReadDisk(ctx, job.Spec.DiskID)
+	if err != nil {
+		return false, err
+	}
+	if !types.Availability(disk.Availability).IsReady() {
+		return true, nil
+	}
+	if job.Spec.Kind == KindExpand {
+		return disk.SizeMB >= job.Spec.expandedSizeMB(), nil
+	}
+	return false, fmt.Errorf("%w: disk %d is available, check it and submit a new job", ErrUnknownSubmission, job.Spec.DiskID)
+}
+
+// findSnapshot returns the snapshot created for the job, nil when there is none
+func (r *Runner) findSnapshot(ctx context.Context, job *Job) (*v1.DiskSnapshot, error) {
+	snapshots, err := r.Disk.ListSnapshots(ctx, job.Spec.DiskID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range snapshots.DiskSnapshots {
+		// snapshots whose metadata cannot be read were not created by a job
+		metadata, err := dedicatedstorage.SnapshotMetadataOf(&snapshots.DiskSnapshots[i])
+		if err == nil && metadata[dedicatedstorage.MetadataKeyJobID] == job.ID {
+			return &snapshots.DiskSnapshots[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// wait waits for the operation of the job, submitted by a previous Run when resumed
+func (r *Runner) wait(ctx context.Context, job *Job, resumed bool) error {
+	if job.Spec.Kind == KindSnapshot {
+		waiter := &dedicatedstorage.SnapshotWaiter{API: r.Disk, Interval: r.WaitInterval, Timeout: r.WaitTimeout}
+		_, err := waiter.WaitForReady(ctx, job.Spec.DiskID, job.ResultSnapshotID)
+		return err
+	}
+
+	startTimeout := r.WaitStartTimeout
+	if startTimeout <= 0 {
+		startTimeout = dedicatedstorage.DefaultDiskStartTimeout
+	}
+	waiter := &dedicatedstorage.DiskOperationWaiter{
+		Reader:       r.StateReader,
+		Interval:     r.WaitInterval,
+		StartTimeout: startTimeout,
+		Timeout:      r.WaitTimeout,
+	}
+	if resumed {
+		// the disk may have left the available state and come back while no runner was polling,
+		// so an operation submitted long ago is only waited for to be available
+		elapsed := r.now().Sub(job.SubmittedAt)
+		if elapsed >= startTimeout {
+			_, err := waiter.WaitForAvailable(ctx, job.Spec.DiskID)
+			return err
+		}
+		waiter.StartTimeout = startTimeout - elapsed
+	}
+
+	var applied func(disk *v1.Disk) bool
+	if job.Spec.Kind == KindExpand {
+		sizeMB := job.Spec.expandedSizeMB()
+		applied = func(disk *v1.Disk) bool { return disk.SizeMB >= sizeMB }
+	}
+	_, err := waiter.WaitForOperation(ctx, job.Spec.DiskID, applied)
+	return err
+}
+
+// finish records the job as done, or failed with err, and returns it
+func (r *Runner) finish(ctx context.Context, id string, jobErr error) (*Job, error) {
+	job, err := r.Store.Update(ctx, id, func(job *Job) error {
+		job.State = StateDone
+		if jobErr != nil {
+			job.State = StateFailed
+			job.Error = jobErr.Error()
+		}
+		job.FinishedAt = r.now()
+		job.UpdatedAt = job.FinishedAt
+		return nil
+	})
+	if err != nil {
+		return nil, dedicatedstorage.NewError("Job.Run", err)
+	}
+	return job, nil
+}
+
+func (s *Spec) expandedSizeMB() int64 {
+	return (&dedicatedstorage.ExpandParams{SizeGiB: s.SizeGiB}).Request().ExpanedSizeMB
+}
+
+// snapshotParams returns the snapshot of the job, whose metadata records the job ID
+func (s *Spec) snapshotParams(jobID string) *dedicatedstorage.SnapshotCreateParams {
+	metadata := maps.Clone(s.Metadata)
+	if metadata == nil {
+		metadata = dedicatedstorage.SnapshotMetadata{}
+	}
+	metadata[dedicatedstorage.MetadataKeyJobID] = jobID
+	return &dedicatedstorage.SnapshotCreateParams{
+		ContractID:  s.ContractID,
+		Name:        s.Name,
+		Description: s.Description,
+		Metadata:    metadata,
+	}
+}
+
+func validateSpec(spec *Spec) error {
+	switch spec.Kind {
+	case KindSnapshot:
+		if _, ok := spec.Metadata[dedicatedstorage.MetadataKeyJobID]; ok {
+			return fmt.Errorf("metadata key %q is reserved for the job ID", dedicatedstorage.MetadataKeyJobID)
+		}
+		// the job ID is not known yet, a placeholder of its length makes sure the metadata fits
+		request, err := spec.snapshotParams(strings.Repeat("0", jobIDLength*2)).Request()
+		if err != nil {
+			return err
+		}
+		return dedicatedstorage.ValidateCreateSnapshotRequest(spec.DiskID, request)
+	case KindRestore:
+		if spec.DiskID <= 0 || spec.SnapshotID <= 0 {
+			return errors.New("restore job requires a disk and a snapshot")
+		}
+		return nil
+	case KindExpand:
+		return dedicatedstorage.ValidateExpandDiskRequest(spec.DiskID, (&dedicatedstorage.ExpandParams{SizeGiB: spec.SizeGiB}).Request())
+	default:
+		return fmt.Errorf("unknown job kind %q", spec.Kind)
+	}
+}
+
+// jobIDLength is the number of random bytes of a job ID
+const jobIDLength = 8
+
+func newJobID() (string, error) {
+	b := make([]byte, jobIDLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sacloud/dedicated-storage-api-go/internal/fileutil"
+)
+
+// storeVersion is the version of the persisted store format
+const storeVersion = 1
+
+// ErrNotFound is returned when a job does not exist.
+var ErrNotFound = errors.New("job not found")
+
+// Store persists jobs.
+type Store interface {
+	Create(ctx context.Context, job *Job) error
+	// Update applies fn to the job and persists the result. Nothing is persisted when fn fails.
+	Update(ctx context.Context, id string, fn func(job *Job) error) (*Job, error)
+	Get(ctx context.Context, id string) (*Job, error)
+	// List returns all jobs in the order they were created.
+	List(ctx context.Context) ([]*Job, error)
+}
+
+type storeFile struct {
+	Version int    `json:"version"`
+	Jobs    []*Job `json:"jobs"`
+}
+
+var _ Store = (*FileStore)(nil)
+
+// FileStore is a Store keeping all jobs in a JSON file.
+// Processes sharing the file are serialized with flock on a sibling ".lock" file.
+type FileStore struct {
+	Path string
+}
+
+// NewFileStore returns a FileStore keeping jobs in path. The file is created on the first write.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+func (s *FileStore) Create(ctx context.Context, job *Job) error {
+	return s.transaction(ctx, true, func(file *storeFile) error {
+		for _, j := range file.Jobs {
+			if j.ID == job.ID {
+				return fmt.Errorf("job %s already exists", job.ID)
+			}
+		}
+		file.Jobs = append(file.Jobs, job)
+		return nil
+	})
+}
+
+func (s *FileStore) Update(ctx context.Context, id string, fn func(job *Job) error) (*Job, error) {
+	var updated *Job
+	err := s.transaction(ctx, true, func(file *storeFile) error {
+		for _, j := range file.Jobs {
+			if j.ID == id {
+				if err := fn(j); err != nil {
+					return err
+				}
+				updated = j
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	})
+	return updated, err
+}
+
+func (s *FileStore) Get(ctx context.Context, id string) (*Job, error) {
+	var found *Job
+	err := s.transaction(ctx, false, func(file *storeFile) error {
+		for _, j := range file.Jobs {
+			if j.ID == id {
+				found = j
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	})
+	return found, err
+}
+
+func (s *FileStore) List(ctx context.Context) ([]*Job, error) {
+	var jobs []*Job
+	err := s.transaction(ctx, false, func(file *storeFile) error {
+		jobs = file.Jobs
+		return nil
+	})
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs, err
+}
+
+// transaction runs fn with the stored jobs under flock and saves them afterwards when write is true
+func (s *FileStore) transaction(ctx context.Context, write bool, fn func(file *storeFile) error) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return err
+	}
+	unlock, err := fileutil.Lock(ctx, s.Path+".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(file); err != nil {
+		return err
+	}
+	if !write {
+		return nil
+	}
+	return s.save(file)
+}
+
+func (s *FileStore) load() (*storeFile, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &storeFile{Version: storeVersion}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid job store %s: %w", s.Path, err)
+	}
+	if file.Version != storeVersion {
+		return nil, fmt.Errorf("unsupported job store version %d in %s", file.Version, s.Path)
+	}
+	return &file, nil
+}
+
+func (s *FileStore) save(file *storeFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFile(s.Path, data, 0o600)
+}
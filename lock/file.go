@@ -0,0 +1,153 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sacloud/dedicated-storage-api-go/internal/fileutil"
+)
+
+var _ Locker = (*FileLocker)(nil)
+
+// FileLocker is a Locker for processes sharing a directory on one host.
+//
+// Each key has a lease file in Dir recording the owner and the expiry of its lease.
+// The lease file is only read and written under flock, which is not held while the
+// lease is, so that a crashed process leaves an expiring lease instead of a stuck lock.
+type FileLocker struct {
+	Dir string
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+}
+
+type fileEntry struct {
+	Owner     string    `json:"owner"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewFileLocker returns a FileLocker keeping its files in dir, which is created when missing.
+func NewFileLocker(dir string) (*FileLocker, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileLocker{Dir: dir}, nil
+}
+
+func (l *FileLocker) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now()
+}
+
+// path returns the lease file of key. Keys are escaped reversibly, "disk/1" to "disk%2F1",
+// so that distinct keys never share a file.
+func (l *FileLocker) path(key string) string {
+	return filepath.Join(l.Dir, url.QueryEscape(key)+".lease")
+}
+
+// update runs fn with the current lease of key under flock and writes back the lease fn returns
+func (l *FileLocker) update(ctx context.Context, key string, fn func(current *fileEntry) (*fileEntry, error)) error {
+	path := l.path(key)
+	unlock, err := fileutil.Lock(ctx, path+".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var current *fileEntry
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		current = &fileEntry{}
+		if err := json.Unmarshal(data, current); err != nil {
+			return fmt.Errorf("invalid lease file %s: %w", path, err)
+		}
+	}
+
+	next, err := fn(current)
+	if err != nil {
+		return err
+	}
+	if next == current {
+		return nil
+	}
+	if next == nil {
+		return os.Remove(path)
+	}
+	data, err = json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFile(path, data, 0o600)
+}
+
+func (l *FileLocker) TryAcquire(ctx context.Context, key, owner string, ttl time.Duration) (Lease, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *fileEntry
+	err = l.update(ctx, key, func(current *fileEntry) (*fileEntry, error) {
+		now := l.now()
+		if current != nil && now.Before(current.ExpiresAt) {
+			return nil, &HeldError{Key: key, Owner: current.Owner, ExpiresAt: current.ExpiresAt}
+		}
+		entry = &fileEntry{Owner: owner, Token: token, ExpiresAt: now.Add(ttlOrDefault(ttl))}
+		return entry, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &lease{
+		key:       key,
+		owner:     owner,
+		expiresAt: entry.ExpiresAt,
+		renew: func(ctx context.Context) (time.Time, error) {
+			var expiresAt time.Time
+			err := l.update(ctx, key, func(current *fileEntry) (*fileEntry, error) {
+				now := l.now()
+				if current == nil || current.Token != token || !now.Before(current.ExpiresAt) {
+					return nil, lostError(key)
+				}
+				expiresAt = now.Add(ttlOrDefault(ttl))
+				return &fileEntry{Owner: current.Owner, Token: token, ExpiresAt: expiresAt}, nil
+			})
+			return expiresAt, err
+		},
+		release: func(ctx context.Context) error {
+			return l.update(ctx, key, func(current *fileEntry) (*fileEntry, error) {
+				if current != nil && current.Token == token {
+					return nil, nil
+				}
+				return current, nil
+			})
+		},
+	}, nil
+}
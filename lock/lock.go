@@ -0,0 +1,153 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lock provides advisory locks with lease timeouts, keyed by resource such as a disk ID.
+//
+// The locks only exclude callers which use the same Locker backend: a file backend for processes
+// on one host, a memory backend for goroutines in one process, or any distributed backend
+// implementing Locker for workers on several hosts.
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is the lease timeout used when none is given.
+const DefaultTTL = 5 * time.Minute
+
+// ErrHeld is wrapped by the errors returned when a lock is held by someone else.
+var ErrHeld = errors.New("lock is held")
+
+// ErrLeaseLost is wrapped by the errors returned when renewing a lease which expired or was released.
+var ErrLeaseLost = errors.New("lease is lost")
+
+// HeldError is returned when a lock is held by an unexpired lease.
+type HeldError struct {
+	Key       string
+	Owner     string
+	ExpiresAt time.Time
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("lock %q is held by %q until %s", e.Key, e.Owner, e.ExpiresAt.Format(time.RFC3339))
+}
+
+func (e *HeldError) Is(target error) bool {
+	return target == ErrHeld
+}
+
+// Lease is an acquired lock. It expires by itself once its timeout passes.
+type Lease interface {
+	Key() string
+	Owner() string
+	ExpiresAt() time.Time
+	// Renew extends the lease by its timeout from now. It returns an error wrapping ErrLeaseLost
+	// when the lease already expired or was released, even if nobody acquired the lock since.
+	Renew(ctx context.Context) error
+	// Release releases the lock. Releasing an expired lease, which may already
+	// be acquired by someone else, does nothing.
+	Release(ctx context.Context) error
+}
+
+// Locker acquires locks. Distributed backends, such as ones based on a database
+// or a key-value store, implement Locker to be used with NewDiskOp.
+type Locker interface {
+	// TryAcquire acquires the lock of key for ttl on behalf of owner without waiting.
+	// It returns *HeldError when the lock is held by an unexpired lease, even one of the same owner.
+	TryAcquire(ctx context.Context, key, owner string, ttl time.Duration) (Lease, error)
+}
+
+// Acquire acquires the lock of key, retrying every interval while it is held until ctx is done.
+// It returns the last *HeldError when ctx is done before the lock is acquired.
+func Acquire(ctx context.Context, locker Locker, key, owner string, ttl, interval time.Duration) (Lease, error) {
+	for {
+		lease, err := locker.TryAcquire(ctx, key, owner, ttl)
+		if !errors.Is(err, ErrHeld) {
+			return lease, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(interval):
+		}
+	}
+}
+
+// DefaultOwner returns an owner identifying the current process, "<hostname>/<pid>".
+func DefaultOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}
+
+type lease struct {
+	key   string
+	owner string
+	// renew returns the new expiry of the lease
+	renew   func(ctx context.Context) (time.Time, error)
+	release func(ctx context.Context) error
+
+	mu        sync.Mutex
+	expiresAt time.Time
+}
+
+func (l *lease) Key() string                       { return l.key }
+func (l *lease) Owner() string                     { return l.owner }
+func (l *lease) Release(ctx context.Context) error { return l.release(ctx) }
+
+func (l *lease) ExpiresAt() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.expiresAt
+}
+
+func (l *lease) Renew(ctx context.Context) error {
+	expiresAt, err := l.renew(ctx)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expiresAt = expiresAt
+	return nil
+}
+
+func lostError(key string) error {
+	return fmt.Errorf("%w: lock %q", ErrLeaseLost, key)
+}
+
+// newToken returns a random token telling leases of the same key apart
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func ttlOrDefault(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return DefaultTTL
+	}
+	return ttl
+}
@@ -0,0 +1,218 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func testLocker(t *testing.T, locker Locker, setNow func(time.Time)) {
+	assert := require.New(t)
+	ctx := t.Context()
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	setNow(now)
+
+	first, err := locker.TryAcquire(ctx, "disk/1", "job-a", time.Minute)
+	assert.NoError(err)
+	assert.Equal(now.Add(time.Minute), first.ExpiresAt())
+
+	_, err = locker.TryAcquire(ctx, "disk/1", "job-b", time.Minute)
+	var held *HeldError
+	assert.True(errors.As(err, &held))
+	assert.True(errors.Is(err, ErrHeld))
+	assert.Equal("job-a", held.Owner)
+
+	other, err := locker.TryAcquire(ctx, "disk/2", "job-b", time.Minute)
+	assert.NoError(err)
+	assert.NoError(other.Release(ctx))
+
+	setNow(now.Add(30 * time.Second))
+	assert.NoError(first.Renew(ctx))
+	assert.Equal(now.Add(90*time.Second), first.ExpiresAt())
+
+	// the expired lease is taken over, and releasing it afterwards must not release the new one
+	setNow(now.Add(2 * time.Minute))
+	assert.True(errors.Is(first.Renew(ctx), ErrLeaseLost), "an expired lease cannot be renewed")
+	second, err := locker.TryAcquire(ctx, "disk/1", "job-b", time.Minute)
+	assert.NoError(err)
+	assert.True(errors.Is(first.Renew(ctx), ErrLeaseLost))
+	assert.NoError(first.Release(ctx))
+	_, err = locker.TryAcquire(ctx, "disk/1", "job-c", time.Minute)
+	assert.True(errors.Is(err, ErrHeld))
+
+	assert.NoError(second.Release(ctx))
+	third, err := locker.TryAcquire(ctx, "disk/1", "job-c", time.Minute)
+	assert.NoError(err)
+	assert.NoError(third.Release(ctx))
+}
+
+func TestMemoryLocker(t *testing.T) {
+	locker := NewMemoryLocker()
+	testLocker(t, locker, func(now time.Time) { locker.Now = func() time.Time { return now } })
+}
+
+func TestFileLocker(t *testing.T) {
+	dir := t.TempDir()
+	locker, err := NewFileLocker(dir)
+	require.NoError(t, err)
+	testLocker(t, locker, func(now time.Time) { locker.Now = func() time.Time { return now } })
+
+	t.Run("shared directory", func(t *testing.T) {
+		assert := require.New(t)
+		another, err := NewFileLocker(dir)
+		assert.NoError(err)
+		another.Now = locker.Now
+
+		lease, err := locker.TryAcquire(t.Context(), "disk/3", "job-a", 0)
+		assert.NoError(err)
+		_, err = another.TryAcquire(t.Context(), "disk/3", "job-b", 0)
+		assert.True(errors.Is(err, ErrHeld))
+		assert.NoError(lease.Release(t.Context()))
+	})
+
+	t.Run("distinct keys", func(t *testing.T) {
+		assert := require.New(t)
+		keys := []string{"disk/1", "disk_1", "disk:1", `disk\1`, "disk%2F1"}
+		paths := map[string]string{}
+		for _, key := range keys {
+			path := locker.path(key)
+			assert.Equal(dir, filepath.Dir(path), key)
+			assert.NotContains(paths, path, "%s collides with %s", key, paths[path])
+			paths[path] = key
+
+			lease, err := locker.TryAcquire(t.Context(), key, "job-a", 0)
+			assert.NoError(err, key)
+			defer lease.Release(t.Context()) //nolint:errcheck
+		}
+	})
+
+	t.Run("no temporary files", func(t *testing.T) {
+		assert := require.New(t)
+		entries, err := os.ReadDir(dir)
+		assert.NoError(err)
+		for _, entry := range entries {
+			name := entry.Name()
+			assert.True(strings.HasSuffix(name, ".lease") || strings.HasSuffix(name, ".lease.lock"), name)
+		}
+	})
+}
+
+func TestAcquire(t *testing.T) {
+	assert := require.New(t)
+	locker := NewMemoryLocker()
+
+	lease, err := locker.TryAcquire(t.Context(), "disk/1", "job-a", time.Minute)
+	assert.NoError(err)
+	time.AfterFunc(20*time.Millisecond, func() { lease.Release(context.Background()) }) //nolint:errcheck
+
+	acquired, err := Acquire(t.Context(), locker, "disk/1", "job-b", time.Minute, 5*time.Millisecond)
+	assert.NoError(err)
+	assert.Equal("job-b", acquired.Owner())
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	_, err = Acquire(ctx, locker, "disk/1", "job-c", time.Minute, 5*time.Millisecond)
+	assert.True(errors.Is(err, ErrHeld))
+}
+
+func TestDiskOp(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	disk := store.AddDisk(v1.Disk{})
+	snapshot := store.AddSnapshot(v1.DiskSnapshot{
+		Disk:                     disk,
+		DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+	})
+
+	locker := NewMemoryLocker()
+	op := NewDiskOp(fake.NewDiskOp(store), locker, &DiskOptions{Owner: "worker"})
+
+	lease, err := locker.TryAcquire(ctx, DiskKey(disk.ID), "other", time.Minute)
+	assert.NoError(err)
+
+	err = op.RestoreFromSnapshot(ctx, disk.ID, snapshot.ID)
+	var held *HeldError
+	assert.True(errors.As(err, &held))
+	assert.Equal("other", held.Owner)
+	_, err = op.ListSnapshots(ctx, disk.ID)
+	assert.NoError(err, "reads are not locked")
+
+	assert.NoError(lease.Release(ctx))
+	assert.NoError(op.RestoreFromSnapshot(ctx, disk.ID, snapshot.ID))
+
+	// the lock is released after the call
+	lease, err = locker.TryAcquire(ctx, DiskKey(disk.ID), "other", time.Minute)
+	assert.NoError(err)
+	assert.NoError(lease.Release(ctx))
+
+	t.Run("operation waiter", func(t *testing.T) {
+		assert := require.New(t)
+		store.OperationStates = []types.Availability{
+			types.AvailabilityAvailable, types.AvailabilityMigrating, types.AvailabilityMigrating,
+			types.AvailabilityMigrating, types.AvailabilityMigrating, types.AvailabilityMigrating,
+		}
+		defer func() { store.OperationStates = nil }()
+
+		// the reads take longer than the TTL, so the lease must be renewed to stay held
+		reader := &lockCheckingReader{DiskStateReader: fake.NewDiskStateReader(store), locker: locker}
+		op := NewDiskOp(fake.NewDiskOp(store), locker, &DiskOptions{
+			Owner: "worker",
+			TTL:   60 * time.Millisecond,
+			OperationWaiter: &dedicatedstorage.DiskOperationWaiter{
+				Reader: reader, Interval: 20 * time.Millisecond, StartTimeout: time.Second, Timeout: time.Second,
+			},
+		})
+		assert.NoError(op.RestoreFromSnapshot(t.Context(), disk.ID, snapshot.ID))
+		assert.Len(reader.held, len(store.OperationStates)+1)
+		for _, held := range reader.held {
+			assert.True(held, "the lock is held until the disk is available again")
+		}
+
+		lease, err := locker.TryAcquire(t.Context(), DiskKey(disk.ID), "other", time.Minute)
+		assert.NoError(err)
+		assert.NoError(lease.Release(t.Context()))
+	})
+}
+
+// lockCheckingReader records whether the lock of the disk is held on every read
+type lockCheckingReader struct {
+	dedicatedstorage.DiskStateReader
+	locker Locker
+	held   []bool
+}
+
+func (r *lockCheckingReader) ReadDisk(ctx context.Context, diskID int64) (*v1.Disk, error) {
+	lease, err := r.locker.TryAcquire(ctx, DiskKey(diskID), "other", time.Minute)
+	if err == nil {
+		lease.Release(ctx) //nolint:errcheck
+	}
+	r.held = append(r.held, errors.Is(err, ErrHeld))
+	return r.DiskStateReader.ReadDisk(ctx, diskID)
+}
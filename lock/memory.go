@@ -0,0 +1,95 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Locker = (*MemoryLocker)(nil)
+
+// MemoryLocker is a Locker for goroutines of a single process.
+type MemoryLocker struct {
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+
+	mu     sync.Mutex
+	leases map[string]*memoryEntry
+}
+
+type memoryEntry struct {
+	owner     string
+	token     string
+	expiresAt time.Time
+}
+
+// NewMemoryLocker returns an empty MemoryLocker.
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{}
+}
+
+func (l *MemoryLocker) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now()
+}
+
+func (l *MemoryLocker) TryAcquire(_ context.Context, key, owner string, ttl time.Duration) (Lease, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if current, ok := l.leases[key]; ok && now.Before(current.expiresAt) {
+		return nil, &HeldError{Key: key, Owner: current.owner, ExpiresAt: current.expiresAt}
+	}
+	if l.leases == nil {
+		l.leases = make(map[string]*memoryEntry)
+	}
+	entry := &memoryEntry{owner: owner, token: token, expiresAt: now.Add(ttlOrDefault(ttl))}
+	l.leases[key] = entry
+
+	return &lease{
+		key:       key,
+		owner:     owner,
+		expiresAt: entry.expiresAt,
+		renew: func(context.Context) (time.Time, error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			now := l.now()
+			current, ok := l.leases[key]
+			if !ok || current.token != token || !now.Before(current.expiresAt) {
+				return time.Time{}, lostError(key)
+			}
+			current.expiresAt = now.Add(ttlOrDefault(ttl))
+			return current.expiresAt, nil
+		},
+		release: func(context.Context) error {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if current, ok := l.leases[key]; ok && current.token == token {
+				delete(l.leases, key)
+			}
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+)
+
+// DefaultRetryInterval is the interval between attempts while waiting for a held lock.
+const DefaultRetryInterval = time.Second
+
+// DiskKey returns the lock key of a disk.
+func DiskKey(diskID int64) string {
+	return fmt.Sprintf("disk/%d", diskID)
+}
+
+// DiskOptions configures NewDiskOp.
+type DiskOptions struct {
+	// Owner identifies the caller in HeldError. DefaultOwner() is used when empty.
+	Owner string
+	// TTL is the lease timeout of the locks. DefaultTTL is used when zero.
+	TTL time.Duration
+	// WaitTimeout is how long to wait for a held lock. Calls fail immediately when zero.
+	WaitTimeout time.Duration
+	// RetryInterval is the interval between attempts while waiting. DefaultRetryInterval is used when zero.
+	RetryInterval time.Duration
+	// OperationWaiter waits for RestoreFromSnapshot and Expand to complete while the lock is held.
+	// When nil, the lock is released as soon as the API accepts the operation, so it only serializes
+	// the submission of operations, not the operations themselves.
+	OperationWaiter *dedicatedstorage.DiskOperationWaiter
+}
+
+var _ dedicatedstorage.DiskAPI = (*diskOp)(nil)
+
+type diskOp struct {
+	dedicatedstorage.DiskAPI
+	locker Locker
+	opts   DiskOptions
+}
+
+// NewDiskOp returns a DiskAPI whose mutating calls hold the lock of the disk while calling api,
+// and while waiting for the operation when DiskOptions.OperationWaiter is set. The lease is renewed
+// in the background every third of its TTL until the call returns.
+// Calls on a disk whose lock is held fail with an error wrapping *HeldError, and calls whose lease
+// is lost in the meantime fail with an error wrapping ErrLeaseLost.
+func NewDiskOp(api dedicatedstorage.DiskAPI, locker Locker, opts *DiskOptions) dedicatedstorage.DiskAPI {
+	op := &diskOp{DiskAPI: api, locker: locker}
+	if opts != nil {
+		op.opts = *opts
+	}
+	if op.opts.Owner == "" {
+		op.opts.Owner = DefaultOwner()
+	}
+	if op.opts.RetryInterval <= 0 {
+		op.opts.RetryInterval = DefaultRetryInterval
+	}
+	return op
+}
+
+func (op *diskOp) CreateSnapshot(ctx context.Context, diskID int64, request *v1.CreateSnapshotRequest) (res *v1.DiskSnapshot, err error) {
+	err = op.withLock(ctx, "Disk.CreateSnapshot", diskID, func(ctx context.Context) error {
+		res, err = op.DiskAPI.CreateSnapshot(ctx, diskID, request)
+		return err
+	})
+	return res, err
+}
+
+func (op *diskOp) UpdateSnapshot(ctx context.Context, diskID, snapshotID int64, request *v1.UpdateSnapshotRequest) (res *v1.DiskSnapshot, err error) {
+	err = op.withLock(ctx, "Disk.UpdateSnapshot", diskID, func(ctx context.Context) error {
+		res, err = op.DiskAPI.UpdateSnapshot(ctx, diskID, snapshotID, request)
+		return err
+	})
+	return res, err
+}
+
+func (op *diskOp) DeleteSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	return op.withLock(ctx, "Disk.DeleteSnapshot", diskID, func(ctx context.Context) error {
+		return op.DiskAPI.DeleteSnapshot(ctx, diskID, snapshotID)
+	})
+}
+
+func (op *diskOp) RestoreFromSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	return op.withLock(ctx, "Disk.RestoreFromSnapshot", diskID, func(ctx context.Context) error {
+		if err := op.DiskAPI.RestoreFromSnapshot(ctx, diskID, snapshotID); err != nil {
+			return err
+		}
+		return op.waitForOperation(ctx, diskID, nil)
+	})
+}
+
+func (op *diskOp) Expand(ctx context.Context, diskID int64, request *v1.ExpandDiskRequest) error {
+	return op.withLock(ctx, "Disk.Expand", diskID, func(ctx context.Context) error {
+		if err := op.DiskAPI.Expand(ctx, diskID, request); err != nil {
+			return err
+		}
+		return op.waitForOperation(ctx, diskID, func(disk *v1.Disk) bool {
+			return request != nil && disk.SizeMB >= request.ExpanedSizeMB
+		})
+	})
+}
+
+func (op *diskOp) waitForOperation(ctx context.Context, diskID int64, applied func(disk *v1.Disk) bool) error {
+	if op.opts.OperationWaiter == nil {
+		return nil
+	}
+	_, err := op.opts.OperationWaiter.WaitForOperation(ctx, diskID, applied)
+	return err
+}
+
+func (op *diskOp) withLock(ctx context.Context, methodName string, diskID int64, fn func(ctx context.Context) error) error {
+	var lease Lease
+	var err error
+	if op.opts.WaitTimeout > 0 {
+		waitCtx, cancel := context.WithTimeout(ctx, op.opts.WaitTimeout)
+		lease, err = Acquire(waitCtx, op.locker, DiskKey(diskID), op.opts.Owner, op.opts.TTL, op.opts.RetryInterval)
+		cancel()
+	} else {
+		lease, err = op.locker.TryAcquire(ctx, DiskKey(diskID), op.opts.Owner, op.opts.TTL)
+	}
+	if err != nil {
+		return dedicatedstorage.NewError(methodName, err)
+	}
+	// release even when ctx is canceled so that the lock is not kept until it expires
+	defer lease.Release(context.WithoutCancel(ctx)) //nolint:errcheck
+
+	leaseCtx, cancel := context.WithCancelCause(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		op.keepAlive(leaseCtx, lease, cancel)
+	}()
+	err = fn(leaseCtx)
+	cancel(nil)
+	<-renewed
+
+	if cause := context.Cause(leaseCtx); errors.Is(cause, ErrLeaseLost) {
+		return dedicatedstorage.NewError(methodName, cause)
+	}
+	return err
+}
+
+// keepAlive renews the lease every third of its TTL until ctx is done. It cancels ctx with the
+// error once the lease is lost, other errors are retried on the next tick while the lease lasts.
+func (op *diskOp) keepAlive(ctx context.Context, lease Lease, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(ttlOrDefault(op.opts.TTL) / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := lease.Renew(ctx); errors.Is(err, ErrLeaseLost) {
+				cancel(err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+)
+
+// ContractFromV1 maps a generated contract to the model.
+func ContractFromV1(contract *v1.DedicatedStorageContract) *Contract {
+	return &Contract{
+		ID:          contract.ID,
+		Name:        contract.Name,
+		Description: contract.Description,
+		Tags:        append([]string{}, contract.Tags...),
+		IconID:      dedicatedstorage.ContractIconID(contract),
+		CreatedAt:   dedicatedstorage.ContractCreatedAt(contract),
+		Plan: Plan{
+			ID:           contract.Plan.ID,
+			Name:         contract.Plan.Name,
+			ServiceClass: types.ServiceClass(contract.Plan.ServiceClass),
+		},
+		Storage: Storage{
+			ID:         contract.Storage.ID,
+			Generation: contract.Storage.Generation,
+			Class:      contract.Storage.Class,
+			Dedicated:  contract.Storage.Dedicated,
+		},
+	}
+}
+
+// PlanFromV1 maps a generated plan to the model.
+func PlanFromV1(plan *v1.DedicatedStorageContractPlan) *Plan {
+	return &Plan{
+		ID:           plan.ID,
+		Name:         plan.Name,
+		ServiceClass: types.ServiceClass(plan.ServiceClass),
+	}
+}
+
+// SnapshotFromV1 maps a generated snapshot to the model.
+func SnapshotFromV1(snapshot *v1.DiskSnapshot) *Snapshot {
+	return &Snapshot{
+		ID:          snapshot.ID,
+		Name:        snapshot.Name,
+		Description: snapshot.Description,
+		CreatedAt:   snapshot.CreatedAt,
+		State:       types.SnapshotState(snapshot.SnapshotState),
+		ContractID:  snapshot.DedicatedStorageContract.ID,
+		Disk:        *DiskFromV1(&snapshot.Disk),
+	}
+}
+
+// DiskFromV1 maps a generated disk to the model.
+func DiskFromV1(disk *v1.Disk) *Disk {
+	return &Disk{
+		ID:                  disk.ID,
+		Name:                disk.Name,
+		SizeMB:              disk.SizeMB,
+		Availability:        types.Availability(disk.Availability),
+		Connection:          types.Connection(disk.Connection),
+		ConnectionOrder:     dedicatedstorage.DiskConnectionOrder(disk),
+		EncryptionAlgorithm: types.EncryptionAlgorithm(disk.EncryptionAlgorithm),
+		KMSKeyID:            dedicatedstorage.DiskKMSKeyID(disk),
+		PlanID:              disk.Plan.ID,
+		Storage: Storage{
+			ID:         disk.Storage.ID,
+			Generation: disk.Storage.Generation,
+			Class:      disk.Storage.Class,
+			Dedicated:  disk.Storage.Dedicated,
+		},
+		BundleID:  dedicatedstorage.DiskBundleID(disk),
+		HostClass: dedicatedstorage.DiskHostClass(disk),
+	}
+}
+
+// PoolUsageFromV1 maps a generated pool usage to the model.
+func PoolUsageFromV1(usage *v1.PoolUsageResponsePoolUsage) *PoolUsage {
+	return &PoolUsage{
+		Data: Pool{
+			TotalGB: usage.DataPool.TotalGB,
+			UsedGB:  usage.DataPool.UsedGB,
+			FreeGB:  usage.DataPool.FreeGB,
+		},
+		Snapshot: Pool{
+			TotalGB: usage.SnapshotPool.TotalGB,
+			UsedGB:  usage.SnapshotPool.UsedGB,
+			FreeGB:  usage.SnapshotPool.FreeGB,
+		},
+	}
+}
+
+func contractsFromV1(contracts []v1.DedicatedStorageContract) []Contract {
+	results := make([]Contract, 0, len(contracts))
+	for i := range contracts {
+		results = append(results, *ContractFromV1(&contracts[i]))
+	}
+	return results
+}
+
+func snapshotsFromV1(snapshots []v1.DiskSnapshot) []Snapshot {
+	results := make([]Snapshot, 0, len(snapshots))
+	for i := range snapshots {
+		results = append(results, *SnapshotFromV1(&snapshots[i]))
+	}
+	return results
+}
+
+func plansFromV1(plans []v1.DedicatedStorageContractPlan) []Plan {
+	results := make([]Plan, 0, len(plans))
+	for i := range plans {
+		results = append(results, *PlanFromV1(&plans[i]))
+	}
+	return results
+}
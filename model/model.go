@@ -0,0 +1,105 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package model is a hand-written domain model of the dedicated storage API.
+//
+// Unlike the types generated in apis/v1, the model only changes in backward compatible ways
+// within a SchemaVersion: changes to the API specification are absorbed by the mapping functions.
+package model
+
+import (
+	"time"
+
+	"github.com/sacloud/dedicated-storage-api-go/types"
+)
+
+// SchemaVersion is the version of the model. It is incremented on incompatible changes.
+const SchemaVersion = 1
+
+// Contract is a dedicated storage contract.
+type Contract struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Tags        []string  `json:"tags"`
+	IconID      int64     `json:"icon_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	Plan        Plan      `json:"plan"`
+	Storage     Storage   `json:"storage"`
+}
+
+// Plan is a plan of contracts.
+type Plan struct {
+	ID           int64              `json:"id"`
+	Name         string             `json:"name"`
+	ServiceClass types.ServiceClass `json:"service_class"`
+}
+
+// Storage is the storage a contract or disk is placed on.
+type Storage struct {
+	ID         int64  `json:"id"`
+	Generation int64  `json:"generation"`
+	Class      string `json:"class"`
+	Dedicated  bool   `json:"dedicated"`
+}
+
+// Snapshot is a disk snapshot.
+type Snapshot struct {
+	ID          int64               `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	CreatedAt   time.Time           `json:"created_at"`
+	State       types.SnapshotState `json:"state"`
+	ContractID  int64               `json:"contract_id"`
+	// Disk is the source disk of the snapshot
+	Disk Disk `json:"disk"`
+}
+
+// Disk is a disk placed on a dedicated storage.
+type Disk struct {
+	ID           int64              `json:"id"`
+	Name         string             `json:"name"`
+	SizeMB       int64              `json:"size_mb"`
+	Availability types.Availability `json:"availability"`
+	Connection   types.Connection   `json:"connection"`
+	// ConnectionOrder is the order in which the disk is connected to its server, zero when detached
+	ConnectionOrder     int64                     `json:"connection_order,omitempty"`
+	EncryptionAlgorithm types.EncryptionAlgorithm `json:"encryption_algorithm"`
+	// KMSKeyID is the ID of the KMS key encrypting the disk, zero when none
+	KMSKeyID int64   `json:"kms_key_id,omitempty"`
+	PlanID   int64   `json:"plan_id"`
+	Storage  Storage `json:"storage"`
+	// BundleID is the ID of the bundle the disk belongs to, zero when none
+	BundleID  int64  `json:"bundle_id,omitempty"`
+	HostClass string `json:"host_class,omitempty"`
+}
+
+// Attached reports whether the disk is connected to a server.
+// It agrees with dedicatedstorage.IsDiskAttached on the disk the model was mapped from.
+func (d *Disk) Attached() bool {
+	return d.ConnectionOrder != 0
+}
+
+// PoolUsage is the usage of the pools of a contract.
+type PoolUsage struct {
+	Data     Pool `json:"data"`
+	Snapshot Pool `json:"snapshot"`
+}
+
+// Pool is the usage of a storage pool.
+type Pool struct {
+	TotalGB int64 `json:"total_gb"`
+	UsedGB  int64 `json:"used_gb"`
+	FreeGB  int64 `json:"free_gb"`
+}
@@ -0,0 +1,110 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/sacloud/dedicated-storage-api-go/model"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelOps(t *testing.T) {
+	store := fake.NewStore()
+	store.AddPlan(v1.DedicatedStorageContractPlan{ID: 1, Name: "plan", ServiceClass: "cloud/dedicated-storage/1"})
+	disk := store.AddDisk(v1.Disk{
+		Availability:    "available",
+		ConnectionOrder: v1.NewNilInt64(1),
+		EncryptionKey:   v1.NewNilDiskEncryptionKey(v1.DiskEncryptionKey{KMSKeyID: v1.NewNilInt64(100)}),
+		SizeMB:          20 * 1024,
+	})
+
+	contractOp := model.NewContractOp(fake.NewContractOp(store))
+	diskOp := model.NewDiskOp(fake.NewDiskOp(store))
+	ctx := t.Context()
+
+	t.Run("contract", func(t *testing.T) {
+		assert := require.New(t)
+		created, err := contractOp.Create(ctx, &dedicatedstorage.ContractCreateParams{PlanID: 1, Name: "contract", IconID: 10})
+		assert.NoError(err)
+		assert.Equal(int64(10), created.IconID)
+		assert.Equal(types.ServiceClass("cloud/dedicated-storage/1"), created.Plan.ServiceClass)
+
+		contracts, err := contractOp.List(ctx)
+		assert.NoError(err)
+		assert.Len(contracts, 1)
+		assert.Equal(created.ID, contracts[0].ID)
+
+		plans, err := contractOp.ListPlans(ctx)
+		assert.NoError(err)
+		assert.Equal("plan", plans[0].Name)
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		assert := require.New(t)
+		contracts, err := contractOp.List(ctx)
+		assert.NoError(err)
+
+		created, err := diskOp.CreateSnapshot(ctx, disk.ID, &dedicatedstorage.SnapshotCreateParams{
+			ContractID: contracts[0].ID,
+			Name:       "snapshot",
+		})
+		assert.NoError(err)
+		assert.Equal(contracts[0].ID, created.ContractID)
+		assert.Equal(types.SnapshotState("available"), created.State)
+		assert.True(created.Disk.Attached())
+		assert.Equal(int64(100), created.Disk.KMSKeyID)
+
+		snapshots, err := contractOp.ListSnapshots(ctx, contracts[0].ID)
+		assert.NoError(err)
+		assert.Len(snapshots, 1)
+
+		assert.NoError(diskOp.Expand(ctx, disk.ID, &dedicatedstorage.ExpandParams{SizeGiB: 40}))
+		expanded, _ := store.Disk(disk.ID)
+		assert.Equal(int64(40*1024), expanded.SizeMB)
+	})
+}
+
+func TestContractFromV1(t *testing.T) {
+	assert := require.New(t)
+	createdAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	contract := model.ContractFromV1(&v1.DedicatedStorageContract{
+		ID:        1,
+		CreatedAt: v1.NewNilDateTime(createdAt),
+		Icon:      v1.OptNilIcon{Set: true, Null: true},
+	})
+	assert.Equal(createdAt, contract.CreatedAt)
+	assert.Zero(contract.IconID)
+	assert.NotNil(contract.Tags)
+}
+
+func TestDiskFromV1_Attached(t *testing.T) {
+	assert := require.New(t)
+	for name, order := range map[string]v1.NilInt64{
+		"null":     {Null: true},
+		"zero":     v1.NewNilInt64(0),
+		"attached": v1.NewNilInt64(2),
+	} {
+		disk := &v1.Disk{ConnectionOrder: order}
+		assert.Equal(dedicatedstorage.IsDiskAttached(disk), model.DiskFromV1(disk).Attached(), name)
+		assert.Equal(name == "attached", dedicatedstorage.IsDiskAttached(disk), name)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+)
+
+// ContractAPI is dedicatedstorage.ContractAPI returning the model.
+type ContractAPI interface {
+	Create(ctx context.Context, params *dedicatedstorage.ContractCreateParams) (*Contract, error)
+	List(ctx context.Context) ([]Contract, error)
+	Read(ctx context.Context, id int64) (*Contract, error)
+	Update(ctx context.Context, id int64, params *dedicatedstorage.ContractUpdateParams) (*Contract, error)
+	Delete(ctx context.Context, id int64) error
+	PoolUsage(ctx context.Context, id int64) (*PoolUsage, error)
+	ListSnapshots(ctx context.Context, id int64) ([]Snapshot, error)
+	ListPlans(ctx context.Context) ([]Plan, error)
+	ReadPlan(ctx context.Context, planID int64) (*Plan, error)
+}
+
+var _ ContractAPI = (*contractOp)(nil)
+
+type contractOp struct {
+	api dedicatedstorage.ContractAPI
+}
+
+// NewContractOp returns a ContractAPI calling api.
+func NewContractOp(api dedicatedstorage.ContractAPI) ContractAPI {
+	return &contractOp{api: api}
+}
+
+func (op *contractOp) Create(ctx context.Context, params *dedicatedstorage.ContractCreateParams) (*Contract, error) {
+	res, err := op.api.Create(ctx, params.Request())
+	if err != nil {
+		return nil, err
+	}
+	return ContractFromV1(res), nil
+}
+
+func (op *contractOp) List(ctx context.Context) ([]Contract, error) {
+	res, err := op.api.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return contractsFromV1(res.DedicatedStorageContracts), nil
+}
+
+func (op *contractOp) Read(ctx context.Context, id int64) (*Contract, error) {
+	res, err := op.api.Read(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ContractFromV1(res), nil
+}
+
+func (op *contractOp) Update(ctx context.Context, id int64, params *dedicatedstorage.ContractUpdateParams) (*Contract, error) {
+	res, err := op.api.Update(ctx, id, params.Request())
+	if err != nil {
+		return nil, err
+	}
+	return ContractFromV1(res), nil
+}
+
+func (op *contractOp) Delete(ctx context.Context, id int64) error {
+	return op.api.Delete(ctx, id)
+}
+
+func (op *contractOp) PoolUsage(ctx context.Context, id int64) (*PoolUsage, error) {
+	res, err := op.api.PoolUsage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return PoolUsageFromV1(res), nil
+}
+
+func (op *contractOp) ListSnapshots(ctx context.Context, id int64) ([]Snapshot, error) {
+	res, err := op.api.ListDiskSnapshots(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return snapshotsFromV1(res.DiskSnapshots), nil
+}
+
+func (op *contractOp) ListPlans(ctx context.Context) ([]Plan, error) {
+	res, err := op.api.ListPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return plansFromV1(res.DedicatedStorageContractPlans), nil
+}
+
+func (op *contractOp) ReadPlan(ctx context.Context, planID int64) (*Plan, error) {
+	res, err := op.api.ReadPlan(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	return PlanFromV1(res), nil
+}
+
+// DiskAPI is dedicatedstorage.DiskAPI returning the model.
+type DiskAPI interface {
+	CreateSnapshot(ctx context.Context, diskID int64, params *dedicatedstorage.SnapshotCreateParams) (*Snapshot, error)
+	ListSnapshots(ctx context.Context, diskID int64) ([]Snapshot, error)
+	UpdateSnapshot(ctx context.Context, diskID, snapshotID int64, params *dedicatedstorage.SnapshotUpdateParams) (*Snapshot, error)
+	DeleteSnapshot(ctx context.Context, diskID, snapshotID int64) error
+	RestoreFromSnapshot(ctx context.Context, diskID, snapshotID int64) error
+	Expand(ctx context.Context, diskID int64, params *dedicatedstorage.ExpandParams) error
+}
+
+var _ DiskAPI = (*diskOp)(nil)
+
+type diskOp struct {
+	api dedicatedstorage.DiskAPI
+}
+
+// NewDiskOp returns a DiskAPI calling api.
+func NewDiskOp(api dedicatedstorage.DiskAPI) DiskAPI {
+	return &diskOp{api: api}
+}
+
+func (op *diskOp) CreateSnapshot(ctx context.Context, diskID int64, params *dedicatedstorage.SnapshotCreateParams) (*Snapshot, error) {
+	req, err := params.Request()
+	if err != nil {
+		return nil, dedicatedstorage.NewError("Disk.CreateSnapshot", err)
+	}
+	res, err := op.api.CreateSnapshot(ctx, diskID, req)
+	if err != nil {
+		return nil, err
+	}
+	return SnapshotFromV1(res), nil
+}
+
+func (op *diskOp) ListSnapshots(ctx context.Context, diskID int64) ([]Snapshot, error) {
+	res, err := op.api.ListSnapshots(ctx, diskID)
+	if err != nil {
+		return nil, err
+	}
+	return snapshotsFromV1(res.DiskSnapshots), nil
+}
+
+func (op *diskOp) UpdateSnapshot(ctx context.Context, diskID, snapshotID int64, params *dedicatedstorage.SnapshotUpdateParams) (*Snapshot, error) {
+	req, err := params.Request()
+	if err != nil {
+		return nil, dedicatedstorage.NewError("Disk.UpdateSnapshot", err)
+	}
+	res, err := op.api.UpdateSnapshot(ctx, diskID, snapshotID, req)
+	if err != nil {
+		return nil, err
+	}
+	return SnapshotFromV1(res), nil
+}
+
+func (op *diskOp) DeleteSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	return op.api.DeleteSnapshot(ctx, diskID, snapshotID)
+}
+
+func (op *diskOp) RestoreFromSnapshot(ctx context.Context, diskID, snapshotID int64) error {
+	return op.api.RestoreFromSnapshot(ctx, diskID, snapshotID)
+}
+
+func (op *diskOp) Expand(ctx context.Context, diskID int64, params *dedicatedstorage.ExpandParams) error {
+	return op.api.Expand(ctx, diskID, params.Request())
+}
@@ -0,0 +1,367 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package multiaccount runs read operations across several accounts and zones concurrently.
+//
+// Accounts are usacloud compatible profiles loaded by saclient-go. Every result is annotated
+// with the account, profile and zone it came from, and failures of some accounts or zones are
+// reported next to the results of the others instead of aborting the whole operation.
+package multiaccount
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	"github.com/sacloud/dedicated-storage-api-go/model"
+	"github.com/sacloud/saclient-go"
+)
+
+// DefaultZones are the zones queried as a last resort, when neither the account, its profile
+// nor the aggregator names any.
+var DefaultZones = []string{"tk1b"}
+
+// DefaultConcurrency is the number of account and zone pairs queried at the same time
+// when Aggregator.Concurrency is zero.
+const DefaultConcurrency = 8
+
+// Account is an account to query.
+type Account struct {
+	// Name labels the results of the account. Profile is used when empty.
+	Name    string `json:"name,omitempty"`
+	Profile string `json:"profile"`
+	// Zones are the zones to query. Aggregator.Zones is used when empty.
+	// LoadAccounts and NewAggregator fill it with the zones of the profile.
+	Zones []string `json:"zones,omitempty"`
+}
+
+func (a *Account) name() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.Profile
+}
+
+// Source is where a result came from.
+type Source struct {
+	Account string `json:"account"`
+	Profile string `json:"profile"`
+	Zone    string `json:"zone"`
+}
+
+func (s Source) String() string {
+	return fmt.Sprintf("account %s (profile %s, zone %s)", s.Account, s.Profile, s.Zone)
+}
+
+// Result is a value annotated with its source.
+type Result[T any] struct {
+	Source
+	Value T `json:"value"`
+}
+
+// Failure is an error of a source.
+type Failure struct {
+	Source
+	Err error `json:"-"`
+	// Message is Err.Error(), for serialization
+	Message string `json:"error"`
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("%s: %v", f.Source, f.Err)
+}
+
+func (f *Failure) Unwrap() error {
+	return f.Err
+}
+
+// Results are the values collected from every source and the failures of the others.
+type Results[T any] struct {
+	Items    []Result[T] `json:"items"`
+	Failures []Failure   `json:"failures"`
+}
+
+// Err returns the failures joined, nil when every source succeeded.
+func (r *Results[T]) Err() error {
+	errs := make([]error, len(r.Failures))
+	for i := range r.Failures {
+		errs[i] = &r.Failures[i]
+	}
+	return errors.Join(errs...)
+}
+
+// Target is the APIs of a source.
+type Target struct {
+	Source
+	Contracts dedicatedstorage.ContractAPI
+	Disks     dedicatedstorage.DiskAPI
+}
+
+// Connector returns the APIs of an account in a zone.
+type Connector func(account *Account, zone string) (dedicatedstorage.ContractAPI, dedicatedstorage.DiskAPI, error)
+
+// ZoneAPIRootURL returns the root URL of the API of the zone.
+func ZoneAPIRootURL(zone string) string {
+	return fmt.Sprintf("https://secure.sakura.ad.jp/cloud/zone/%s/api/cloud/1.0/", zone)
+}
+
+// accountVariables are the environment variables naming the profile, the credentials or the zone,
+// after the prefixes of saclient-go, which would take precedence over the profile of every account
+var accountVariables = []string{
+	"PROFILE",
+	"ACCESS_TOKEN", "ACCESS_TOKEN_SECRET",
+	"PRIVATE_KEY", "PRIVATE_KEY_PATH", "SERVICE_PRINCIPAL_ID", "SERVICE_PRINCIPAL_KEY_ID", "TOKEN_ENDPOINT",
+	"ZONE", "ZONES", "DEFAULT_ZONE",
+}
+
+// accountEnviron returns environ without the accountVariables
+func accountEnviron(environ []string) []string {
+	var filtered []string
+	for _, v := range environ {
+		name, _, _ := strings.Cut(v, "=")
+		account := false
+		for _, prefix := range []string{"SAKURA_", "SAKURACLOUD_", "USACLOUD_"} {
+			if suffix, ok := strings.CutPrefix(name, prefix); ok && slices.Contains(accountVariables, suffix) {
+				account = true
+				break
+			}
+		}
+		if !account {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
+
+// NewProfileConnector returns a Connector creating clients from the profiles of the accounts.
+// environ is given to saclient.Client.SetEnviron without the variables naming a profile, credentials
+// or a zone, such as SAKURA_ACCESS_TOKEN, which would override the profile of every account.
+func NewProfileConnector(environ []string) Connector {
+	environ = accountEnviron(environ)
+	return func(account *Account, zone string) (dedicatedstorage.ContractAPI, dedicatedstorage.DiskAPI, error) {
+		var client saclient.Client
+		if err := client.SetEnviron(environ); err != nil {
+			return nil, nil, err
+		}
+		if err := client.FlagSet(flag.ContinueOnError).Parse([]string{"-profile", account.Profile}); err != nil {
+			return nil, nil, err
+		}
+		c, err := dedicatedstorage.NewClientWithAPIRootURL(&client, ZoneAPIRootURL(zone))
+		if err != nil {
+			return nil, nil, err
+		}
+		return dedicatedstorage.NewContractOp(c), dedicatedstorage.NewDiskOp(c), nil
+	}
+}
+
+// LoadAccounts returns an account for every saved profile, in the order of their names,
+// with the zones of the profile.
+func LoadAccounts(environ []string) ([]Account, error) {
+	profiles := saclient.NewProfileOp(environ)
+	names, err := profiles.List()
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]Account, 0, len(names))
+	for _, name := range names {
+		zones, err := profileZones(profiles, name)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, Account{Profile: name, Zones: zones})
+	}
+	return accounts, nil
+}
+
+// profileZones returns the zones of the profile: its "Zones", or its "Zone" when it has no list.
+func profileZones(profiles saclient.ProfileAPI, name string) ([]string, error) {
+	profile, err := profiles.Read(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading profile %s: %w", name, err)
+	}
+	var zones []string
+	if list, ok := profile.Get("Zones"); ok {
+		values, _ := list.([]any)
+		for _, v := range values {
+			if zone, ok := v.(string); ok && zone != "" {
+				zones = append(zones, zone)
+			}
+		}
+	}
+	if len(zones) == 0 {
+		if v, ok := profile.Get("Zone"); ok {
+			if zone, _ := v.(string); zone != "" {
+				zones = []string{zone}
+			}
+		}
+	}
+	return zones, nil
+}
+
+// Aggregator queries every zone of every account.
+type Aggregator struct {
+	Accounts []Account
+	Connect  Connector
+	// Zones are the zones of the accounts without their own. DefaultZones is used when empty.
+	Zones []string
+	// Concurrency is the maximum number of sources queried at the same time. DefaultConcurrency is used when zero.
+	Concurrency int
+}
+
+// NewAggregator returns an Aggregator connecting to the accounts with their profiles.
+// The accounts without zones get the zones of their profile. A profile which cannot be read
+// is left to fail when connecting.
+func NewAggregator(accounts []Account, environ []string) *Aggregator {
+	profiles := saclient.NewProfileOp(environ)
+	accounts = slices.Clone(accounts)
+	for i := range accounts {
+		if len(accounts[i].Zones) == 0 {
+			accounts[i].Zones, _ = profileZones(profiles, accounts[i].Profile)
+		}
+	}
+	return &Aggregator{Accounts: accounts, Connect: NewProfileConnector(environ)}
+}
+
+// Collect calls fn for every source concurrently and collects the values it returns.
+// Items are ordered as the accounts and their zones, whatever order the sources complete in.
+func Collect[T any](ctx context.Context, a *Aggregator, fn func(ctx context.Context, target *Target) ([]T, error)) *Results[T] {
+	type outcome struct {
+		items   []Result[T]
+		failure *Failure
+	}
+
+	var sources []Source
+	var accounts []*Account
+	for i := range a.Accounts {
+		account := &a.Accounts[i]
+		zones := account.Zones
+		if len(zones) == 0 {
+			zones = a.Zones
+		}
+		if len(zones) == 0 {
+			zones = DefaultZones
+		}
+		for _, zone := range zones {
+			sources = append(sources, Source{Account: account.name(), Profile: account.Profile, Zone: zone})
+			accounts = append(accounts, account)
+		}
+	}
+
+	concurrency := a.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	outcomes := make([]outcome, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fail := func(err error) {
+				outcomes[i].failure = &Failure{Source: source, Err: err, Message: err.Error()}
+			}
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			}
+
+			contracts, disks, err := a.Connect(accounts[i], source.Zone)
+			if err != nil {
+				fail(fmt.Errorf("connecting: %w", err))
+				return
+			}
+			values, err := fn(ctx, &Target{Source: source, Contracts: contracts, Disks: disks})
+			if err != nil {
+				fail(err)
+				return
+			}
+			for _, v := range values {
+				outcomes[i].items = append(outcomes[i].items, Result[T]{Source: source, Value: v})
+			}
+		}()
+	}
+	wg.Wait()
+
+	results := &Results[T]{Items: []Result[T]{}, Failures: []Failure{}}
+	for _, o := range outcomes {
+		results.Items = append(results.Items, o.items...)
+		if o.failure != nil {
+			results.Failures = append(results.Failures, *o.failure)
+		}
+	}
+	return results
+}
+
+// ListContracts lists the contracts of every source.
+func (a *Aggregator) ListContracts(ctx context.Context) *Results[model.Contract] {
+	return Collect(ctx, a, func(ctx context.Context, target *Target) ([]model.Contract, error) {
+		return model.NewContractOp(target.Contracts).List(ctx)
+	})
+}
+
+// ListSnapshots lists the snapshots of every contract of every source.
+func (a *Aggregator) ListSnapshots(ctx context.Context) *Results[model.Snapshot] {
+	return Collect(ctx, a, func(ctx context.Context, target *Target) ([]model.Snapshot, error) {
+		op := model.NewContractOp(target.Contracts)
+		contracts, err := op.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var snapshots []model.Snapshot
+		for _, contract := range contracts {
+			s, err := op.ListSnapshots(ctx, contract.ID)
+			if err != nil {
+				return nil, err
+			}
+			snapshots = append(snapshots, s...)
+		}
+		return snapshots, nil
+	})
+}
+
+// PoolUsage is the pool usage of a contract.
+type PoolUsage struct {
+	ContractID int64 `json:"contract_id"`
+	model.PoolUsage
+}
+
+// ListPoolUsages reads the pool usage of every contract of every source.
+func (a *Aggregator) ListPoolUsages(ctx context.Context) *Results[PoolUsage] {
+	return Collect(ctx, a, func(ctx context.Context, target *Target) ([]PoolUsage, error) {
+		op := model.NewContractOp(target.Contracts)
+		contracts, err := op.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var usages []PoolUsage
+		for _, contract := range contracts {
+			usage, err := op.PoolUsage(ctx, contract.ID)
+			if err != nil {
+				return nil, err
+			}
+			usages = append(usages, PoolUsage{ContractID: contract.ID, PoolUsage: *usage})
+		}
+		return usages, nil
+	})
+}
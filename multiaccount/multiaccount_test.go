@@ -0,0 +1,127 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiaccount
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregator(t *testing.T) {
+	assert := require.New(t)
+
+	stores := map[string]*fake.Store{}
+	for _, key := range []string{"prod/tk1b", "prod/is1b", "dev/tk1b"} {
+		store := fake.NewStore()
+		contract := store.AddContract(v1.DedicatedStorageContract{Name: key})
+		disk := store.AddDisk(v1.Disk{})
+		store.AddSnapshot(v1.DiskSnapshot{
+			SnapshotState:            "available",
+			Disk:                     disk,
+			DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+		})
+		stores[key] = store
+	}
+	stores["prod/is1b"].Fail("Contract.List", errors.New("unavailable"))
+
+	aggregator := &Aggregator{
+		Accounts: []Account{
+			{Name: "production", Profile: "prod", Zones: []string{"tk1b", "is1b"}},
+			{Profile: "dev"},
+			{Profile: "unknown"},
+		},
+		Connect: func(account *Account, zone string) (dedicatedstorage.ContractAPI, dedicatedstorage.DiskAPI, error) {
+			store, ok := stores[account.Profile+"/"+zone]
+			if !ok {
+				return nil, nil, errors.New("no such profile")
+			}
+			return fake.NewContractOp(store), fake.NewDiskOp(store), nil
+		},
+		Concurrency: 2,
+	}
+
+	contracts := aggregator.ListContracts(t.Context())
+	assert.Len(contracts.Items, 2)
+	assert.Equal(Source{Account: "production", Profile: "prod", Zone: "tk1b"}, contracts.Items[0].Source)
+	assert.Equal("prod/tk1b", contracts.Items[0].Value.Name)
+	assert.Equal(Source{Account: "dev", Profile: "dev", Zone: "tk1b"}, contracts.Items[1].Source)
+
+	assert.Len(contracts.Failures, 2)
+	assert.Equal("is1b", contracts.Failures[0].Zone)
+	assert.Contains(contracts.Failures[0].Message, "unavailable")
+	assert.Equal("unknown", contracts.Failures[1].Profile)
+	assert.Error(contracts.Err())
+
+	snapshots := aggregator.ListSnapshots(t.Context())
+	assert.Len(snapshots.Items, 2)
+	usages := aggregator.ListPoolUsages(t.Context())
+	assert.Len(usages.Items, 2)
+}
+
+func TestLoadAccounts(t *testing.T) {
+	assert := require.New(t)
+
+	dir := t.TempDir()
+	for name, config := range map[string]string{
+		"prod":  `{"Zone": "tk1b", "Zones": ["tk1b", "is1b"]}`,
+		"dev":   `{"Zone": "is1a"}`,
+		"plain": `{}`,
+	} {
+		assert.NoError(os.MkdirAll(filepath.Join(dir, name), 0o700))
+		assert.NoError(os.WriteFile(filepath.Join(dir, name, "config.json"), []byte(config), 0o600))
+	}
+
+	accounts, err := LoadAccounts([]string{"SAKURA_PROFILE_DIR=" + dir})
+	assert.NoError(err)
+	assert.Equal([]Account{
+		{Profile: "dev", Zones: []string{"is1a"}},
+		{Profile: "plain"},
+		{Profile: "prod", Zones: []string{"tk1b", "is1b"}},
+	}, accounts)
+
+	aggregator := NewAggregator([]Account{{Profile: "prod"}, {Profile: "dev", Zones: []string{"tk1a"}}, {Profile: "missing"}},
+		[]string{"SAKURA_PROFILE_DIR=" + dir})
+	assert.Equal([]string{"tk1b", "is1b"}, aggregator.Accounts[0].Zones)
+	assert.Equal([]string{"tk1a"}, aggregator.Accounts[1].Zones, "the zones of the account come first")
+	assert.Empty(aggregator.Accounts[2].Zones)
+
+	contracts, disks, err := NewProfileConnector([]string{"SAKURA_PROFILE_DIR=" + dir})(&accounts[0], "is1b")
+	assert.NoError(err)
+	assert.NotNil(contracts)
+	assert.NotNil(disks)
+}
+
+func TestAccountEnviron(t *testing.T) {
+	assert := require.New(t)
+	environ := []string{
+		"SAKURA_ACCESS_TOKEN=token",
+		"SAKURACLOUD_ACCESS_TOKEN_SECRET=secret",
+		"SAKURA_SERVICE_PRINCIPAL_ID=principal",
+		"SAKURA_ZONE=is1b",
+		"SAKURACLOUD_ZONES=tk1b,is1b",
+		"USACLOUD_PROFILE=prod",
+		"SAKURA_PROFILE_DIR=/profiles",
+		"SAKURA_RETRY_MAX=3",
+		"HOME=/home/user",
+	}
+	assert.Equal([]string{"SAKURA_PROFILE_DIR=/profiles", "SAKURA_RETRY_MAX=3", "HOME=/home/user"}, accountEnviron(environ))
+}
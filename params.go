@@ -0,0 +1,200 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage
+
+import (
+	"time"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/packages-go/size"
+)
+
+// ContractCreateParams is the parameters of ContractAPI.Create.
+type ContractCreateParams struct {
+	PlanID      int64
+	Name        string
+	Description string
+	Tags        []string
+	// IconID is the ID of the icon, no icon when zero
+	IconID int64
+}
+
+// Request returns the request for ContractAPI.Create.
+func (p *ContractCreateParams) Request() v1.CreateDedicatedStorageContractRequest {
+	return v1.CreateDedicatedStorageContractRequest{
+		DedicatedStorageContract: v1.CreateDedicatedStorageContractRequestDedicatedStorageContract{
+			Plan:        v1.CreateDedicatedStorageContractRequestDedicatedStorageContractPlan{ID: p.PlanID},
+			Name:        p.Name,
+			Description: p.Description,
+			Tags:        p.Tags,
+			Icon:        iconOf(p.IconID),
+		},
+	}
+}
+
+// ContractUpdateParams is the parameters of ContractAPI.Update.
+type ContractUpdateParams struct {
+	Name        string
+	Description string
+	Tags        []string
+	// IconID is the ID of the icon. Zero removes the icon of the contract.
+	IconID int64
+}
+
+// Request returns the request for ContractAPI.Update.
+func (p *ContractUpdateParams) Request() v1.UpdateDedicatedStorageContractRequest {
+	icon := iconOf(p.IconID)
+	if p.IconID == 0 {
+		// the API keeps the icon when the field is left out
+		icon.SetToNull()
+	}
+	return v1.UpdateDedicatedStorageContractRequest{
+		DedicatedStorageContract: v1.UpdateDedicatedStorageContractRequestDedicatedStorageContract{
+			Name:        p.Name,
+			Description: p.Description,
+			Tags:        p.Tags,
+			Icon:        icon,
+		},
+	}
+}
+
+// ContractUpdateParamsOf returns the parameters which keep the contract as it is.
+func ContractUpdateParamsOf(contract *v1.DedicatedStorageContract) *ContractUpdateParams {
+	return &ContractUpdateParams{
+		Name:        contract.Name,
+		Description: contract.Description,
+		Tags:        append([]string(nil), contract.Tags...),
+		IconID:      ContractIconID(contract),
+	}
+}
+
+// SnapshotCreateParams is the parameters of DiskAPI.CreateSnapshot.
+type SnapshotCreateParams struct {
+	ContractID  int64
+	Name        string
+	Description string
+	// Metadata is appended to the description when not empty
+	Metadata SnapshotMetadata
+}
+
+// Request returns the request for DiskAPI.CreateSnapshot.
+// It fails only when the metadata does not fit in the description.
+func (p *SnapshotCreateParams) Request() (*v1.CreateSnapshotRequest, error) {
+	description, err := EncodeSnapshotDescription(p.Description, p.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.CreateSnapshotRequest{
+		DiskSnapshot: v1.CreateSnapshotRequestDiskSnapshot{
+			DedicatedStorageContract: v1.CreateSnapshotRequestDiskSnapshotDedicatedStorageContract{ID: p.ContractID},
+			Name:                     p.Name,
+			Description:              description,
+		},
+	}, nil
+}
+
+// SnapshotUpdateParams is the parameters of DiskAPI.UpdateSnapshot.
+type SnapshotUpdateParams struct {
+	Name        string
+	Description string
+	// Metadata is appended to the description when not empty
+	Metadata SnapshotMetadata
+}
+
+// Request returns the request for DiskAPI.UpdateSnapshot.
+// It fails only when the metadata does not fit in the description.
+func (p *SnapshotUpdateParams) Request() (*v1.UpdateSnapshotRequest, error) {
+	description, err := EncodeSnapshotDescription(p.Description, p.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.UpdateSnapshotRequest{
+		DiskSnapshot: v1.UpdateSnapshotRequestDiskSnapshot{
+			Name:        p.Name,
+			Description: description,
+		},
+	}, nil
+}
+
+// ExpandParams is the parameters of DiskAPI.Expand.
+type ExpandParams struct {
+	// SizeGiB is the size of the disk after expansion, in GiB
+	SizeGiB int64
+}
+
+// Request returns the request for DiskAPI.Expand.
+func (p *ExpandParams) Request() *v1.ExpandDiskRequest {
+	return &v1.ExpandDiskRequest{ExpanedSizeMB: p.SizeGiB * size.GiB}
+}
+
+// ContractIconID returns the icon ID of the contract, zero when it has no icon.
+func ContractIconID(contract *v1.DedicatedStorageContract) int64 {
+	if !contract.Icon.Set || contract.Icon.Null {
+		return 0
+	}
+	return contract.Icon.Value.ID
+}
+
+// ContractCreatedAt returns the creation time of the contract, the zero time when unknown.
+func ContractCreatedAt(contract *v1.DedicatedStorageContract) time.Time {
+	if contract.CreatedAt.Null {
+		return time.Time{}
+	}
+	return contract.CreatedAt.Value
+}
+
+// DiskKMSKeyID returns the ID of the KMS key encrypting the disk, zero when none.
+func DiskKMSKeyID(disk *v1.Disk) int64 {
+	if disk.EncryptionKey.Null || disk.EncryptionKey.Value.KMSKeyID.Null {
+		return 0
+	}
+	return disk.EncryptionKey.Value.KMSKeyID.Value
+}
+
+// DiskConnectionOrder returns the order in which the disk is connected to its server, zero when detached.
+func DiskConnectionOrder(disk *v1.Disk) int64 {
+	if disk.ConnectionOrder.Null {
+		return 0
+	}
+	return disk.ConnectionOrder.Value
+}
+
+// DiskBundleID returns the ID of the bundle the disk belongs to, zero when none.
+func DiskBundleID(disk *v1.Disk) int64 {
+	if disk.BundleInfo.Null || disk.BundleInfo.Value.ID.Null {
+		return 0
+	}
+	return disk.BundleInfo.Value.ID.Value
+}
+
+// DiskHostClass returns the host class of the bundle the disk belongs to, empty when none.
+func DiskHostClass(disk *v1.Disk) string {
+	if disk.BundleInfo.Null || disk.BundleInfo.Value.HostClass.Null {
+		return ""
+	}
+	return disk.BundleInfo.Value.HostClass.Value
+}
+
+// DiskSizeGiB returns the size of the disk in GiB, rounded down.
+func DiskSizeGiB(disk *v1.Disk) int64 {
+	return disk.SizeMB / size.GiB
+}
+
+func iconOf(id int64) v1.OptNilIcon {
+	if id == 0 {
+		return v1.OptNilIcon{}
+	}
+	return v1.NewOptNilIcon(v1.Icon{ID: id})
+}
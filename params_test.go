@@ -0,0 +1,116 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParams(t *testing.T) {
+	t.Run("contract", func(t *testing.T) {
+		assert := require.New(t)
+		params := &dedicatedstorage.ContractCreateParams{PlanID: 1, Name: "name", Tags: []string{"tag"}, IconID: 2}
+		req := params.Request()
+		assert.Equal(int64(1), req.DedicatedStorageContract.Plan.ID)
+		assert.Equal(v1.NewOptNilIcon(v1.Icon{ID: 2}), req.DedicatedStorageContract.Icon)
+
+		params.IconID = 0
+		assert.False(params.Request().DedicatedStorageContract.Icon.Set)
+
+		contract := &v1.DedicatedStorageContract{
+			Name:      "name",
+			Tags:      []string{"tag"},
+			Icon:      v1.NewOptNilIcon(v1.Icon{ID: 3}),
+			CreatedAt: v1.NilDateTime{Null: true},
+		}
+		update := dedicatedstorage.ContractUpdateParamsOf(contract)
+		assert.Equal(int64(3), update.IconID)
+		assert.Equal("name", update.Request().DedicatedStorageContract.Name)
+		assert.True(dedicatedstorage.ContractCreatedAt(contract).IsZero())
+
+		// no icon in an update clears it, the API keeps the icon when the field is left out
+		update.IconID = 0
+		icon := update.Request().DedicatedStorageContract.Icon
+		assert.True(icon.Set)
+		assert.True(icon.Null)
+		data, err := json.Marshal(update.Request())
+		assert.NoError(err)
+		assert.Contains(string(data), `"Icon":null`)
+	})
+
+	t.Run("clear icon", func(t *testing.T) {
+		assert := require.New(t)
+		store := fake.NewStore()
+		contract := store.AddContract(v1.DedicatedStorageContract{Name: "name", Icon: v1.NewOptNilIcon(v1.Icon{ID: 3})})
+		op := fake.NewContractOp(store)
+
+		// an unset icon is kept
+		updated, err := op.Update(t.Context(), contract.ID, v1.UpdateDedicatedStorageContractRequest{
+			DedicatedStorageContract: v1.UpdateDedicatedStorageContractRequestDedicatedStorageContract{Name: "renamed"},
+		})
+		assert.NoError(err)
+		assert.Equal(int64(3), dedicatedstorage.ContractIconID(updated))
+
+		params := dedicatedstorage.ContractUpdateParamsOf(updated)
+		params.IconID = 0
+		updated, err = op.Update(t.Context(), contract.ID, params.Request())
+		assert.NoError(err)
+		assert.Zero(dedicatedstorage.ContractIconID(updated))
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		assert := require.New(t)
+		params := &dedicatedstorage.SnapshotCreateParams{
+			ContractID:  1,
+			Name:        "name",
+			Description: "text",
+			Metadata:    dedicatedstorage.SnapshotMetadata{dedicatedstorage.MetadataKeyPurpose: "test"},
+		}
+		req, err := params.Request()
+		assert.NoError(err)
+		assert.NoError(dedicatedstorage.ValidateCreateSnapshotRequest(1, req))
+
+		text, metadata, err := dedicatedstorage.DecodeSnapshotDescription(req.DiskSnapshot.Description)
+		assert.NoError(err)
+		assert.Equal("text", text)
+		assert.Equal("test", metadata[dedicatedstorage.MetadataKeyPurpose])
+	})
+
+	t.Run("expand", func(t *testing.T) {
+		assert := require.New(t)
+		req := (&dedicatedstorage.ExpandParams{SizeGiB: 40}).Request()
+		assert.Equal(int64(40*1024), req.ExpanedSizeMB)
+		assert.Equal(int64(40), dedicatedstorage.DiskSizeGiB(&v1.Disk{SizeMB: req.ExpanedSizeMB}))
+	})
+
+	t.Run("disk accessors", func(t *testing.T) {
+		assert := require.New(t)
+		disk := &v1.Disk{
+			EncryptionKey:   v1.NewNilDiskEncryptionKey(v1.DiskEncryptionKey{KMSKeyID: v1.NewNilInt64(10)}),
+			ConnectionOrder: v1.NilInt64{Null: true},
+			BundleInfo:      v1.NewNilDiskBundleInfo(v1.DiskBundleInfo{ID: v1.NewNilInt64(20), HostClass: v1.NewNilString("dedicated")}),
+		}
+		assert.Equal(int64(10), dedicatedstorage.DiskKMSKeyID(disk))
+		assert.Zero(dedicatedstorage.DiskConnectionOrder(disk))
+		assert.Equal(int64(20), dedicatedstorage.DiskBundleID(disk))
+		assert.Equal("dedicated", dedicatedstorage.DiskHostClass(disk))
+	})
+}
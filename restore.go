@@ -0,0 +1,265 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+)
+
+// PurposePreRestore is the MetadataKeyPurpose value of safety snapshots taken before restores.
+const PurposePreRestore = "pre-restore"
+
+// metadataKeyRestoredID records which snapshot was restored after taking a safety snapshot
+const metadataKeyRestoredID = "restored"
+
+var (
+	// ErrInsufficientSnapshotPool is returned when the snapshot pool lacks space for a safety snapshot.
+	ErrInsufficientSnapshotPool = errors.New("insufficient snapshot pool space")
+	// ErrNoSnapshotForPointInTime is returned when no completed snapshot matches the point in time.
+	ErrNoSnapshotForPointInTime = errors.New("no snapshot found for the point in time")
+)
+
+// SafeRestoreResult is the result of RestoreAPI.RestoreWithSafetySnapshot.
+type SafeRestoreResult struct {
+	// SafetySnapshot is the snapshot of the disk taken right before the restore.
+	// Restoring it rolls back the restore.
+	SafetySnapshot *v1.DiskSnapshot
+	// RestoredSnapshotID is the ID of the snapshot the disk was restored from
+	RestoredSnapshotID int64
+}
+
+// PointInTimeSelection decides which snapshot is chosen for a point in time.
+type PointInTimeSelection string
+
+const (
+	// PointInTimeBefore chooses the latest snapshot created at or before the point in time
+	PointInTimeBefore PointInTimeSelection = "before"
+	// PointInTimeNearest chooses the snapshot created nearest to the point in time, before or after it
+	PointInTimeNearest PointInTimeSelection = "nearest"
+)
+
+// PointInTimeStrategy configures RestoreAPI.RestoreToPointInTime.
+type PointInTimeStrategy struct {
+	// Selection is PointInTimeBefore when empty
+	Selection PointInTimeSelection
+	// Confirm is called with the chosen plan and the restore is executed only when it returns true.
+	// Nothing is restored when nil.
+	Confirm func(plan *PointInTimePlan) bool
+}
+
+// PointInTimePlan is the snapshot chosen for a point in time.
+type PointInTimePlan struct {
+	DiskID   int64
+	Target   time.Time
+	Snapshot *v1.DiskSnapshot
+	// Offset is the creation time of the snapshot relative to Target; negative when created before it
+	Offset time.Duration
+	// DataLossWindow is the period before Target whose changes are not in the snapshot
+	DataLossWindow time.Duration
+	// Restored reports whether the restore was executed
+	Restored bool
+}
+
+func (p *PointInTimePlan) String() string {
+	return fmt.Sprintf("disk %d: restore snapshot %d (%s, created at %s) for %s, losing changes made in %s before it",
+		p.DiskID, p.Snapshot.ID, p.Snapshot.Name, p.Snapshot.CreatedAt.Format(time.RFC3339),
+		p.Target.Format(time.RFC3339), p.DataLossWindow)
+}
+
+type RestoreAPI interface {
+	RestoreWithSafetySnapshot(ctx context.Context, diskID, snapshotID int64) (*SafeRestoreResult, error)
+	RestoreToPointInTime(ctx context.Context, diskID int64, t time.Time, strategy PointInTimeStrategy) (*PointInTimePlan, error)
+}
+
+var _ RestoreAPI = (*restoreOp)(nil)
+
+type restoreOp struct {
+	contract ContractAPI
+	disk     DiskAPI
+	waiter   *SnapshotWaiter
+	reader   DiskStateReader
+	now      func() time.Time
+}
+
+// RestoreOptions configures NewRestoreOpWithOptions.
+type RestoreOptions struct {
+	// Waiter waits for safety snapshots. A waiter with the default interval and timeout is used when nil.
+	Waiter *SnapshotWaiter
+	// StateReader reads the current size of disks, which safety snapshots need room for.
+	// A reader based on ListSnapshots is used when nil; it only sees the size of the disk at
+	// its latest snapshot, so use a reader of the disk itself such as iaas.Integration when
+	// the disk may have been expanded since.
+	StateReader DiskStateReader
+}
+
+// NewRestoreOp returns a RestoreAPI. When waiter is nil, a waiter with the default
+// interval and timeout is used.
+func NewRestoreOp(contract ContractAPI, disk DiskAPI, waiter *SnapshotWaiter) RestoreAPI {
+	return NewRestoreOpWithOptions(contract, disk, &RestoreOptions{Waiter: waiter})
+}
+
+// NewRestoreOpWithOptions returns a RestoreAPI configured by opts.
+func NewRestoreOpWithOptions(contract ContractAPI, disk DiskAPI, opts *RestoreOptions) RestoreAPI {
+	op := &restoreOp{contract: contract, disk: disk, now: time.Now}
+	if opts != nil {
+		op.waiter, op.reader = opts.Waiter, opts.StateReader
+	}
+	if op.waiter == nil {
+		op.waiter = NewSnapshotWaiter(disk)
+	}
+	if op.reader == nil {
+		op.reader = NewSnapshotDiskStateReader(disk)
+	}
+	return op
+}
+
+// RestoreWithSafetySnapshot takes a snapshot of the current disk state, waits for it to complete,
+// and then restores the disk from the snapshot.
+//
+// It refuses to proceed when the snapshot pool of the contract has less free space than the current disk size.
+// If waiting for the safety snapshot or the restore fails after the safety snapshot is taken, the result
+// is returned along with the error so that the safety snapshot can be found, retried with or deleted.
+// RestoredSnapshotID is zero when the restore was not requested.
+func (op *restoreOp) RestoreWithSafetySnapshot(ctx context.Context, diskID, snapshotID int64) (*SafeRestoreResult, error) {
+	const methodName = "Restore.WithSafetySnapshot"
+
+	target, err := op.findSnapshot(ctx, diskID, snapshotID)
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+	if !types.SnapshotState(target.SnapshotState).IsReady() {
+		return nil, NewError(methodName, fmt.Errorf("snapshot %d is not available: %s", snapshotID, target.SnapshotState))
+	}
+
+	// the embedded disk has the size at the time of the snapshot, the disk may have been expanded since
+	disk, err := op.reader.ReadDisk(ctx, diskID)
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+	requiredMB := max(disk.SizeMB, target.Disk.SizeMB)
+
+	contractID := target.DedicatedStorageContract.ID
+	usage, err := op.contract.PoolUsage(ctx, contractID)
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+	if freeMB := usage.SnapshotPool.FreeGB * 1024; freeMB < requiredMB {
+		return nil, NewError(methodName, fmt.Errorf("%w: %dMB required, %dMB free", ErrInsufficientSnapshotPool, requiredMB, freeMB))
+	}
+
+	name := fmt.Sprintf("pre-restore-%d-%s", snapshotID, op.now().UTC().Format("20060102150405"))
+	safety, err := CreateSnapshotWithMetadata(ctx, op.disk, diskID, &v1.CreateSnapshotRequest{
+		DiskSnapshot: v1.CreateSnapshotRequestDiskSnapshot{
+			DedicatedStorageContract: v1.CreateSnapshotRequestDiskSnapshotDedicatedStorageContract{ID: contractID},
+			Name:                     name,
+			Description:              fmt.Sprintf("taken before restoring snapshot %d", snapshotID),
+		},
+	}, SnapshotMetadata{
+		MetadataKeyPurpose:    PurposePreRestore,
+		metadataKeyRestoredID: strconv.FormatInt(snapshotID, 10),
+	})
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+	ready, err := op.waiter.WaitForReady(ctx, diskID, safety.ID)
+	if err != nil {
+		return &SafeRestoreResult{SafetySnapshot: safety}, NewError(methodName, err)
+	}
+	safety = ready
+
+	result := &SafeRestoreResult{SafetySnapshot: safety, RestoredSnapshotID: snapshotID}
+	if err := op.disk.RestoreFromSnapshot(ctx, diskID, snapshotID); err != nil {
+		return result, NewError(methodName, err)
+	}
+	return result, nil
+}
+
+// RestoreToPointInTime chooses the completed snapshot of the disk for the point in time t and
+// restores it if strategy.Confirm approves the plan.
+func (op *restoreOp) RestoreToPointInTime(ctx context.Context, diskID int64, t time.Time, strategy PointInTimeStrategy) (*PointInTimePlan, error) {
+	const methodName = "Restore.ToPointInTime"
+
+	selection := strategy.Selection
+	if selection == "" {
+		selection = PointInTimeBefore
+	}
+	if selection != PointInTimeBefore && selection != PointInTimeNearest {
+		return nil, NewError(methodName, fmt.Errorf("unknown selection: %q", selection))
+	}
+
+	snapshots, err := op.disk.ListSnapshots(ctx, diskID)
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+
+	var chosen *v1.DiskSnapshot
+	var chosenDistance time.Duration
+	for i := range snapshots.DiskSnapshots {
+		snapshot := &snapshots.DiskSnapshots[i]
+		if !types.SnapshotState(snapshot.SnapshotState).IsReady() {
+			continue
+		}
+		offset := snapshot.CreatedAt.Sub(t)
+		if selection == PointInTimeBefore && offset > 0 {
+			continue
+		}
+		distance := offset.Abs()
+		if chosen == nil || distance < chosenDistance {
+			chosen, chosenDistance = snapshot, distance
+		}
+	}
+	if chosen == nil {
+		return nil, NewError(methodName, fmt.Errorf("%w: disk %d, %s", ErrNoSnapshotForPointInTime, diskID, t.Format(time.RFC3339)))
+	}
+
+	plan := &PointInTimePlan{
+		DiskID:   diskID,
+		Target:   t,
+		Snapshot: chosen,
+		Offset:   chosen.CreatedAt.Sub(t),
+	}
+	if plan.Offset < 0 {
+		plan.DataLossWindow = -plan.Offset
+	}
+
+	if strategy.Confirm == nil || !strategy.Confirm(plan) {
+		return plan, nil
+	}
+	if err := op.disk.RestoreFromSnapshot(ctx, diskID, chosen.ID); err != nil {
+		return plan, NewError(methodName, err)
+	}
+	plan.Restored = true
+	return plan, nil
+}
+
+func (op *restoreOp) findSnapshot(ctx context.Context, diskID, snapshotID int64) (*v1.DiskSnapshot, error) {
+	snapshots, err := op.disk.ListSnapshots(ctx, diskID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range snapshots.DiskSnapshots {
+		if snapshots.DiskSnapshots[i].ID == snapshotID {
+			return &snapshots.DiskSnapshots[i], nil
+		}
+	}
+	return nil, fmt.Errorf("snapshot %d not found on disk %d", snapshotID, diskID)
+}
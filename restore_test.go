@@ -0,0 +1,186 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreOp_RestoreWithSafetySnapshot(t *testing.T) {
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	disk := store.AddDisk(v1.Disk{SizeMB: 20 * 1024})
+	target := store.AddSnapshot(v1.DiskSnapshot{
+		SnapshotState:            "available",
+		Disk:                     disk,
+		DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+	})
+
+	diskOp := fake.NewDiskOp(store)
+	op := dedicatedstorage.NewRestoreOp(fake.NewContractOp(store), diskOp, newTestWaiter(diskOp))
+
+	t.Run("insufficient pool", func(t *testing.T) {
+		assert := require.New(t)
+		store.SetPoolUsage(contract.ID, v1.PoolUsageResponsePoolUsage{
+			SnapshotPool: v1.PoolUsageResponsePoolUsageSnapshotPool{TotalGB: 100, UsedGB: 90, FreeGB: 10},
+		})
+
+		_, err := op.RestoreWithSafetySnapshot(t.Context(), disk.ID, target.ID)
+		assert.True(errors.Is(err, dedicatedstorage.ErrInsufficientSnapshotPool))
+		assert.Len(store.Snapshots(), 1)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		assert := require.New(t)
+		store.SetPoolUsage(contract.ID, v1.PoolUsageResponsePoolUsage{
+			SnapshotPool: v1.PoolUsageResponsePoolUsageSnapshotPool{TotalGB: 100, FreeGB: 100},
+		})
+
+		result, err := op.RestoreWithSafetySnapshot(t.Context(), disk.ID, target.ID)
+		assert.NoError(err)
+		assert.Equal(target.ID, result.RestoredSnapshotID)
+		assert.NotEqual(target.ID, result.SafetySnapshot.ID)
+
+		metadata, err := dedicatedstorage.SnapshotMetadataOf(result.SafetySnapshot)
+		assert.NoError(err)
+		assert.Equal(dedicatedstorage.PurposePreRestore, metadata[dedicatedstorage.MetadataKeyPurpose])
+
+		calls := store.Calls()
+		assert.Equal("Disk.RestoreFromSnapshot", calls[len(calls)-1])
+	})
+
+	t.Run("restore failure keeps safety snapshot", func(t *testing.T) {
+		assert := require.New(t)
+		store.Fail("Disk.RestoreFromSnapshot", errors.New("busy"))
+		defer store.Fail("Disk.RestoreFromSnapshot", nil)
+
+		result, err := op.RestoreWithSafetySnapshot(t.Context(), disk.ID, target.ID)
+		assert.Error(err)
+		assert.NotNil(result.SafetySnapshot)
+		_, ok := store.Snapshot(result.SafetySnapshot.ID)
+		assert.True(ok)
+	})
+
+	t.Run("safety snapshot failure", func(t *testing.T) {
+		assert := require.New(t)
+		store.InitialSnapshotState = "failed"
+		defer func() { store.InitialSnapshotState = "" }()
+		calls := len(store.Calls())
+
+		result, err := op.RestoreWithSafetySnapshot(t.Context(), disk.ID, target.ID)
+		assert.True(errors.Is(err, dedicatedstorage.ErrSnapshotFailed))
+		assert.NotNil(result.SafetySnapshot)
+		assert.Zero(result.RestoredSnapshotID)
+		_, ok := store.Snapshot(result.SafetySnapshot.ID)
+		assert.True(ok)
+		assert.NotContains(store.Calls()[calls:], "Disk.RestoreFromSnapshot")
+	})
+
+	t.Run("unknown snapshot", func(t *testing.T) {
+		_, err := op.RestoreWithSafetySnapshot(t.Context(), disk.ID, target.ID+1000)
+		require.New(t).Error(err)
+	})
+
+	t.Run("expanded disk", func(t *testing.T) {
+		assert := require.New(t)
+		// room for the disk at the time of the snapshot, not after its expansion
+		store.SetPoolUsage(contract.ID, v1.PoolUsageResponsePoolUsage{
+			SnapshotPool: v1.PoolUsageResponsePoolUsageSnapshotPool{TotalGB: 100, UsedGB: 70, FreeGB: 30},
+		})
+		assert.NoError(diskOp.Expand(t.Context(), disk.ID, &v1.ExpandDiskRequest{ExpanedSizeMB: 40 * 1024}))
+		snapshots := len(store.Snapshots())
+
+		op := dedicatedstorage.NewRestoreOpWithOptions(fake.NewContractOp(store), diskOp, &dedicatedstorage.RestoreOptions{
+			Waiter:      newTestWaiter(diskOp),
+			StateReader: fake.NewDiskStateReader(store),
+		})
+		_, err := op.RestoreWithSafetySnapshot(t.Context(), disk.ID, target.ID)
+		assert.True(errors.Is(err, dedicatedstorage.ErrInsufficientSnapshotPool))
+		assert.ErrorContains(err, "40960MB required")
+		assert.Len(store.Snapshots(), snapshots)
+	})
+}
+
+func TestRestoreOp_RestoreToPointInTime(t *testing.T) {
+	base := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	disk := store.AddDisk(v1.Disk{})
+	snapshot := func(name, state string, createdAt time.Time) v1.DiskSnapshot {
+		return store.AddSnapshot(v1.DiskSnapshot{
+			Name:                     name,
+			SnapshotState:            state,
+			CreatedAt:                createdAt,
+			Disk:                     disk,
+			DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+		})
+	}
+	midnight := snapshot("00:00", "available", base)
+	snapshot("02:00", "failed", base.Add(2*time.Hour))
+	fourAM := snapshot("04:00", "available", base.Add(4*time.Hour))
+
+	diskOp := fake.NewDiskOp(store)
+	op := dedicatedstorage.NewRestoreOp(fake.NewContractOp(store), diskOp, nil)
+	threeAM := base.Add(3 * time.Hour)
+
+	t.Run("before", func(t *testing.T) {
+		assert := require.New(t)
+		plan, err := op.RestoreToPointInTime(t.Context(), disk.ID, threeAM, dedicatedstorage.PointInTimeStrategy{})
+		assert.NoError(err)
+		assert.Equal(midnight.ID, plan.Snapshot.ID)
+		assert.Equal(3*time.Hour, plan.DataLossWindow)
+		assert.False(plan.Restored)
+		assert.NotContains(store.Calls(), "Disk.RestoreFromSnapshot")
+	})
+
+	t.Run("nearest", func(t *testing.T) {
+		assert := require.New(t)
+		plan, err := op.RestoreToPointInTime(t.Context(), disk.ID, threeAM, dedicatedstorage.PointInTimeStrategy{
+			Selection: dedicatedstorage.PointInTimeNearest,
+		})
+		assert.NoError(err)
+		assert.Equal(fourAM.ID, plan.Snapshot.ID)
+		assert.Equal(time.Hour, plan.Offset)
+		assert.Zero(plan.DataLossWindow)
+	})
+
+	t.Run("confirmed", func(t *testing.T) {
+		assert := require.New(t)
+		var shown string
+		plan, err := op.RestoreToPointInTime(t.Context(), disk.ID, threeAM, dedicatedstorage.PointInTimeStrategy{
+			Confirm: func(plan *dedicatedstorage.PointInTimePlan) bool {
+				shown = plan.String()
+				return true
+			},
+		})
+		assert.NoError(err)
+		assert.True(plan.Restored)
+		assert.Contains(shown, "00:00")
+		assert.Contains(store.Calls(), "Disk.RestoreFromSnapshot")
+	})
+
+	t.Run("no snapshot", func(t *testing.T) {
+		_, err := op.RestoreToPointInTime(t.Context(), disk.ID, base.Add(-time.Hour), dedicatedstorage.PointInTimeStrategy{})
+		require.New(t).True(errors.Is(err, dedicatedstorage.ErrNoSnapshotForPointInTime))
+	})
+}
@@ -0,0 +1,186 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+)
+
+// GroupSnapshot is a set of snapshots taken together from multiple disks.
+type GroupSnapshot struct {
+	GroupID string
+	// Snapshots are the completed snapshots, in the order of the requested disk IDs
+	Snapshots []*v1.DiskSnapshot
+}
+
+type SnapshotGroupAPI interface {
+	CreateGroupSnapshot(ctx context.Context, contractID int64, diskIDs []int64, name string) (*GroupSnapshot, error)
+}
+
+var _ SnapshotGroupAPI = (*snapshotGroupOp)(nil)
+
+type snapshotGroupOp struct {
+	disk   DiskAPI
+	waiter *SnapshotWaiter
+}
+
+// NewSnapshotGroupOp returns a SnapshotGroupAPI. When waiter is nil, a waiter with the default
+// interval and timeout is used.
+func NewSnapshotGroupOp(disk DiskAPI, waiter *SnapshotWaiter) SnapshotGroupAPI {
+	if waiter == nil {
+		waiter = NewSnapshotWaiter(disk)
+	}
+	return &snapshotGroupOp{disk: disk, waiter: waiter}
+}
+
+// CreateGroupSnapshot takes snapshots of all disks as close together as possible, tags them
+// with a shared group ID in their description metadata and waits for all of them to complete.
+//
+// If any member fails to be created or to complete, the snapshots created so far are deleted
+// and the returned error describes both the failures and any failed deletion.
+func (op *snapshotGroupOp) CreateGroupSnapshot(ctx context.Context, contractID int64, diskIDs []int64, name string) (*GroupSnapshot, error) {
+	const methodName = "SnapshotGroup.Create"
+
+	if len(diskIDs) == 0 {
+		return nil, NewError(methodName, errors.New("disk IDs are required"))
+	}
+	seen := make(map[int64]bool, len(diskIDs))
+	for _, id := range diskIDs {
+		if seen[id] {
+			return nil, NewError(methodName, fmt.Errorf("duplicated disk ID: %d", id))
+		}
+		seen[id] = true
+	}
+
+	groupID, err := newSnapshotGroupID()
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+	description, err := EncodeSnapshotDescription("consistency group "+groupID, SnapshotMetadata{MetadataKeyGroupID: groupID})
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+	request := &v1.CreateSnapshotRequest{
+		DiskSnapshot: v1.CreateSnapshotRequestDiskSnapshot{
+			DedicatedStorageContract: v1.CreateSnapshotRequestDiskSnapshotDedicatedStorageContract{ID: contractID},
+			Name:                     name,
+			Description:              description,
+		},
+	}
+
+	snapshots := make([]*v1.DiskSnapshot, len(diskIDs))
+	errs := make([]error, len(diskIDs))
+
+	// fire all requests at once after every goroutine is ready
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, diskID := range diskIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			snapshots[i], errs[i] = op.disk.CreateSnapshot(ctx, diskID, request)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if err := joinDiskErrors(diskIDs, errs); err != nil {
+		return nil, NewError(methodName, errors.Join(err, op.rollback(ctx, diskIDs, snapshots)))
+	}
+
+	for i, diskID := range diskIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var ready *v1.DiskSnapshot
+			ready, errs[i] = op.waiter.WaitForReady(ctx, diskID, snapshots[i].ID)
+			if errs[i] == nil {
+				snapshots[i] = ready
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := joinDiskErrors(diskIDs, errs); err != nil {
+		return nil, NewError(methodName, errors.Join(err, op.rollback(ctx, diskIDs, snapshots)))
+	}
+	return &GroupSnapshot{GroupID: groupID, Snapshots: snapshots}, nil
+}
+
+// rollback deletes the created snapshots. It keeps going even when ctx is already canceled.
+func (op *snapshotGroupOp) rollback(ctx context.Context, diskIDs []int64, snapshots []*v1.DiskSnapshot) error {
+	ctx = context.WithoutCancel(ctx)
+	var errs []error
+	for i, snapshot := range snapshots {
+		if snapshot == nil {
+			continue
+		}
+		if err := op.disk.DeleteSnapshot(ctx, diskIDs[i], snapshot.ID); err != nil {
+			errs = append(errs, fmt.Errorf("rollback snapshot %d of disk %d: %w", snapshot.ID, diskIDs[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func joinDiskErrors(diskIDs []int64, errs []error) error {
+	var joined []error
+	for i, err := range errs {
+		if err != nil {
+			joined = append(joined, fmt.Errorf("disk %d: %w", diskIDs[i], err))
+		}
+	}
+	return errors.Join(joined...)
+}
+
+const snapshotGroupPrefix = "snapshot-group:"
+
+// SnapshotGroupDescription returns the snapshot description which tags it with the group ID.
+//
+// Deprecated: CreateGroupSnapshot stores the group ID as MetadataKeyGroupID in the description
+// metadata, use EncodeSnapshotDescription instead. SnapshotGroupID still reads descriptions
+// returned by this function.
+func SnapshotGroupDescription(groupID string) string {
+	return snapshotGroupPrefix + groupID
+}
+
+// SnapshotGroupID returns the group ID tagged by CreateGroupSnapshot, if any.
+func SnapshotGroupID(snapshot *v1.DiskSnapshot) (string, bool) {
+	if groupID, ok := strings.CutPrefix(snapshot.Description, snapshotGroupPrefix); ok {
+		return groupID, groupID != ""
+	}
+	metadata, err := SnapshotMetadataOf(snapshot)
+	if err != nil {
+		return "", false
+	}
+	groupID, ok := metadata[MetadataKeyGroupID]
+	return groupID, ok && groupID != ""
+}
+
+func newSnapshotGroupID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
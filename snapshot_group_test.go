@@ -0,0 +1,111 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWaiter(api dedicatedstorage.DiskAPI) *dedicatedstorage.SnapshotWaiter {
+	return &dedicatedstorage.SnapshotWaiter{API: api, Interval: time.Millisecond, Timeout: time.Second}
+}
+
+func TestSnapshotGroupOp_CreateGroupSnapshot(t *testing.T) {
+	assert := require.New(t)
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	data := store.AddDisk(v1.Disk{Name: "data"})
+	wal := store.AddDisk(v1.Disk{Name: "wal"})
+	store.InitialSnapshotState = "migrating"
+
+	diskOp := fake.NewDiskOp(store)
+	op := dedicatedstorage.NewSnapshotGroupOp(diskOp, newTestWaiter(diskOp))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		for _, v := range store.Snapshots() {
+			store.SetSnapshotState(v.ID, "available")
+		}
+	}()
+
+	group, err := op.CreateGroupSnapshot(t.Context(), contract.ID, []int64{data.ID, wal.ID}, "db")
+	assert.NoError(err)
+	assert.NotEmpty(group.GroupID)
+	assert.Len(group.Snapshots, 2)
+	assert.Equal(data.ID, group.Snapshots[0].Disk.ID)
+	assert.Equal(wal.ID, group.Snapshots[1].Disk.ID)
+	for _, v := range group.Snapshots {
+		assert.Equal("available", v.SnapshotState)
+		groupID, ok := dedicatedstorage.SnapshotGroupID(v)
+		assert.True(ok)
+		assert.Equal(group.GroupID, groupID)
+	}
+
+	groupID, ok := dedicatedstorage.SnapshotGroupID(&v1.DiskSnapshot{Description: dedicatedstorage.SnapshotGroupDescription("legacy")}) //nolint:staticcheck
+	assert.True(ok)
+	assert.Equal("legacy", groupID)
+}
+
+func TestSnapshotGroupOp_Rollback(t *testing.T) {
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	data := store.AddDisk(v1.Disk{Name: "data"})
+	wal := store.AddDisk(v1.Disk{Name: "wal"})
+	diskOp := fake.NewDiskOp(store)
+	op := dedicatedstorage.NewSnapshotGroupOp(diskOp, newTestWaiter(diskOp))
+
+	t.Run("creation failure", func(t *testing.T) {
+		assert := require.New(t)
+		_, err := op.CreateGroupSnapshot(t.Context(), contract.ID, []int64{data.ID, wal.ID + 1}, "db")
+		assert.Error(err)
+		assert.Empty(store.Snapshots())
+	})
+
+	t.Run("completion failure", func(t *testing.T) {
+		assert := require.New(t)
+		store.InitialSnapshotState = "failed"
+		_, err := op.CreateGroupSnapshot(t.Context(), contract.ID, []int64{data.ID, wal.ID}, "db")
+		assert.True(errors.Is(err, dedicatedstorage.ErrSnapshotFailed))
+		assert.Empty(store.Snapshots())
+	})
+
+	t.Run("transient read error", func(t *testing.T) {
+		assert := require.New(t)
+		store.InitialSnapshotState = "available"
+		store.Fail("Disk.ListSnapshots", errors.New("service unavailable"))
+		time.AfterFunc(10*time.Millisecond, func() { store.Fail("Disk.ListSnapshots", nil) })
+
+		group, err := op.CreateGroupSnapshot(t.Context(), contract.ID, []int64{data.ID, wal.ID}, "db")
+		assert.NoError(err)
+		assert.Len(group.Snapshots, 2)
+		for _, v := range group.Snapshots {
+			assert.NoError(diskOp.DeleteSnapshot(t.Context(), v.Disk.ID, v.ID))
+		}
+	})
+
+	t.Run("duplicated disk", func(t *testing.T) {
+		assert := require.New(t)
+		_, err := op.CreateGroupSnapshot(t.Context(), contract.ID, []int64{data.ID, data.ID}, "db")
+		assert.Error(err)
+	})
+}
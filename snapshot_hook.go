@@ -0,0 +1,257 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"time"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+)
+
+const (
+	DefaultHookTimeout = time.Minute
+	// DefaultHookWaitDelay is how long a command hook waits for its output to be closed once it exits
+	DefaultHookWaitDelay = 5 * time.Second
+	// MaxHookOutputSize is the maximum number of bytes of hook output kept in HookResult
+	MaxHookOutputSize = 64 * 1024
+
+	HookPhasePre  = "pre"
+	HookPhasePost = "post"
+)
+
+var (
+	// ErrPreHookFailed is returned when a pre-hook fails and the snapshot is not taken.
+	ErrPreHookFailed = errors.New("pre-hook failed")
+	// ErrPostHookFailed is returned when a post-hook fails.
+	ErrPostHookFailed = errors.New("post-hook failed")
+)
+
+// Hook is a user-defined action run before or after taking a snapshot,
+// such as freezing a filesystem or putting a database into backup mode.
+type Hook interface {
+	Name() string
+	// Run runs the hook and returns its output.
+	Run(ctx context.Context) (string, error)
+}
+
+// FuncHook runs a Go function.
+type FuncHook struct {
+	HookName string
+	Func     func(ctx context.Context) (string, error)
+}
+
+func (h *FuncHook) Name() string { return h.HookName }
+func (h *FuncHook) Run(ctx context.Context) (string, error) {
+	if h.Func == nil {
+		return "", fmt.Errorf("hook %q has no function", h.HookName)
+	}
+	return h.Func(ctx)
+}
+
+// CommandHook runs a local command. Its output is the combined stdout and stderr.
+//
+// A command may leave a background process holding its output open, such as a script starting
+// a process which keeps a filesystem frozen. The output is then read for WaitDelay after the command
+// exits, and the hook succeeds with what was read so far unless the command itself failed.
+type CommandHook struct {
+	HookName string
+	Path     string
+	Args     []string
+	Dir      string
+	// Env is the environment of the command. The current environment is used when nil.
+	Env []string
+	// WaitDelay is how long to read the output after the command exits or is killed.
+	// DefaultHookWaitDelay is used when zero.
+	WaitDelay time.Duration
+}
+
+func (h *CommandHook) Name() string { return h.HookName }
+func (h *CommandHook) Run(ctx context.Context) (string, error) {
+	cmd := exec.CommandContext(ctx, h.Path, h.Args...) //nolint:gosec
+	cmd.Dir = h.Dir
+	cmd.Env = h.Env
+	cmd.WaitDelay = h.WaitDelay
+	if cmd.WaitDelay <= 0 {
+		cmd.WaitDelay = DefaultHookWaitDelay
+	}
+	out, err := cmd.CombinedOutput()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// the command succeeded, only its output was left open
+		err = nil
+	}
+	return string(out), err
+}
+
+// HTTPHook sends an HTTP request and fails unless the response status is 2xx.
+// Its output is the response body.
+type HTTPHook struct {
+	HookName string
+	Method   string
+	URL      string
+	Header   http.Header
+	Body     []byte
+	// Client is the HTTP client. http.DefaultClient is used when nil.
+	Client *http.Client
+}
+
+func (h *HTTPHook) Name() string { return h.HookName }
+func (h *HTTPHook) Run(ctx context.Context) (string, error) {
+	method := h.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, h.URL, bytes.NewReader(h.Body))
+	if err != nil {
+		return "", err
+	}
+	for k, v := range h.Header {
+		req.Header[k] = v
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, MaxHookOutputSize))
+	if err != nil {
+		return string(body), err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return string(body), fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return string(body), nil
+}
+
+// HookResult is the outcome of a single hook run.
+type HookResult struct {
+	Name     string
+	Phase    string
+	Output   string
+	Error    error
+	Duration time.Duration
+}
+
+// SnapshotHooks are the hooks run around CreateSnapshot.
+type SnapshotHooks struct {
+	// Pre hooks are run in order before the snapshot is taken. The first failure aborts the snapshot.
+	Pre []Hook
+	// Post hooks are always run in order after the snapshot is taken or aborted,
+	// even when the context is canceled.
+	Post []Hook
+	// Timeout is the timeout of each hook. DefaultHookTimeout is used when zero.
+	Timeout time.Duration
+}
+
+// HookedSnapshot is the result of HookedSnapshotAPI.CreateSnapshot.
+type HookedSnapshot struct {
+	// Snapshot is nil when the snapshot was not taken
+	Snapshot    *v1.DiskSnapshot
+	HookResults []HookResult
+}
+
+type HookedSnapshotAPI interface {
+	CreateSnapshot(ctx context.Context, diskID int64, request *v1.CreateSnapshotRequest) (*HookedSnapshot, error)
+}
+
+var _ HookedSnapshotAPI = (*hookedSnapshotOp)(nil)
+
+type hookedSnapshotOp struct {
+	disk  DiskAPI
+	hooks SnapshotHooks
+}
+
+// NewHookedSnapshotOp returns a HookedSnapshotAPI taking snapshots via disk with the hooks.
+func NewHookedSnapshotOp(disk DiskAPI, hooks SnapshotHooks) HookedSnapshotAPI {
+	return &hookedSnapshotOp{disk: disk, hooks: hooks}
+}
+
+// CreateSnapshot runs the pre-hooks, takes the snapshot and then runs the post-hooks.
+//
+// The returned HookedSnapshot is non-nil even when an error is returned, so that the hook
+// results and the snapshot, if taken, are available to the caller.
+func (op *hookedSnapshotOp) CreateSnapshot(ctx context.Context, diskID int64, request *v1.CreateSnapshotRequest) (*HookedSnapshot, error) {
+	const methodName = "Disk.CreateSnapshotWithHooks"
+
+	result := &HookedSnapshot{}
+	var errs []error
+
+	preOK := true
+	for _, hook := range op.hooks.Pre {
+		r := op.run(ctx, hook, HookPhasePre)
+		result.HookResults = append(result.HookResults, r)
+		if r.Error != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrPreHookFailed, r.Name, r.Error))
+			preOK = false
+			break
+		}
+	}
+
+	if preOK {
+		snapshot, err := op.disk.CreateSnapshot(ctx, diskID, request)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		result.Snapshot = snapshot
+	}
+
+	postCtx := context.WithoutCancel(ctx)
+	for _, hook := range op.hooks.Post {
+		r := op.run(postCtx, hook, HookPhasePost)
+		result.HookResults = append(result.HookResults, r)
+		if r.Error != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrPostHookFailed, r.Name, r.Error))
+		}
+	}
+
+	if len(errs) > 0 {
+		return result, NewError(methodName, errors.Join(errs...))
+	}
+	return result, nil
+}
+
+func (op *hookedSnapshotOp) run(ctx context.Context, hook Hook, phase string) HookResult {
+	timeout := op.hooks.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	started := time.Now()
+	output, err := hook.Run(ctx)
+	if len(output) > MaxHookOutputSize {
+		output = output[:MaxHookOutputSize]
+	}
+	return HookResult{
+		Name:     hook.Name(),
+		Phase:    phase,
+		Output:   output,
+		Error:    err,
+		Duration: time.Since(started),
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookedSnapshotOp_CreateSnapshot(t *testing.T) {
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	disk := store.AddDisk(v1.Disk{})
+	request := &v1.CreateSnapshotRequest{
+		DiskSnapshot: v1.CreateSnapshotRequestDiskSnapshot{
+			DedicatedStorageContract: v1.CreateSnapshotRequestDiskSnapshotDedicatedStorageContract{ID: contract.ID},
+			Name:                     "snapshot",
+		},
+	}
+
+	var calls []string
+	hook := func(name string, err error) dedicatedstorage.Hook {
+		return &dedicatedstorage.FuncHook{
+			HookName: name,
+			Func: func(context.Context) (string, error) {
+				calls = append(calls, name)
+				return name + " done", err
+			},
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		assert := require.New(t)
+		calls = nil
+
+		op := dedicatedstorage.NewHookedSnapshotOp(fake.NewDiskOp(store), dedicatedstorage.SnapshotHooks{
+			Pre:  []dedicatedstorage.Hook{hook("freeze", nil)},
+			Post: []dedicatedstorage.Hook{hook("thaw", nil)},
+		})
+		result, err := op.CreateSnapshot(t.Context(), disk.ID, request)
+		assert.NoError(err)
+		assert.NotNil(result.Snapshot)
+		assert.Equal([]string{"freeze", "thaw"}, calls)
+		assert.Len(result.HookResults, 2)
+		assert.Equal(dedicatedstorage.HookPhasePre, result.HookResults[0].Phase)
+		assert.Equal("thaw done", result.HookResults[1].Output)
+	})
+
+	t.Run("pre-hook failure", func(t *testing.T) {
+		assert := require.New(t)
+		calls = nil
+		before := len(store.Snapshots())
+
+		op := dedicatedstorage.NewHookedSnapshotOp(fake.NewDiskOp(store), dedicatedstorage.SnapshotHooks{
+			Pre:  []dedicatedstorage.Hook{hook("freeze", errors.New("busy")), hook("never", nil)},
+			Post: []dedicatedstorage.Hook{hook("thaw", nil)},
+		})
+		result, err := op.CreateSnapshot(t.Context(), disk.ID, request)
+		assert.True(errors.Is(err, dedicatedstorage.ErrPreHookFailed))
+		assert.Nil(result.Snapshot)
+		assert.Equal([]string{"freeze", "thaw"}, calls)
+		assert.Len(store.Snapshots(), before)
+	})
+
+	t.Run("post-hook failure", func(t *testing.T) {
+		assert := require.New(t)
+
+		op := dedicatedstorage.NewHookedSnapshotOp(fake.NewDiskOp(store), dedicatedstorage.SnapshotHooks{
+			Post: []dedicatedstorage.Hook{hook("thaw", errors.New("failed"))},
+		})
+		result, err := op.CreateSnapshot(t.Context(), disk.ID, request)
+		assert.True(errors.Is(err, dedicatedstorage.ErrPostHookFailed))
+		assert.NotNil(result.Snapshot)
+	})
+}
+
+func TestHTTPHook(t *testing.T) {
+	assert := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte(r.Method + " " + r.Header.Get("X-Test"))) //nolint:errcheck,gosec
+	}))
+	defer server.Close()
+
+	hook := &dedicatedstorage.HTTPHook{URL: server.URL, Header: http.Header{"X-Test": {"value"}}}
+	out, err := hook.Run(t.Context())
+	assert.NoError(err)
+	assert.Equal("POST value", out)
+
+	hook = &dedicatedstorage.HTTPHook{Method: http.MethodGet, URL: server.URL + "/fail"}
+	_, err = hook.Run(t.Context())
+	assert.Error(err)
+}
+
+func TestCommandHook(t *testing.T) {
+	path, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo not found")
+	}
+	assert := require.New(t)
+
+	hook := &dedicatedstorage.CommandHook{Path: path, Args: []string{"hello"}}
+	out, err := hook.Run(t.Context())
+	assert.NoError(err)
+	assert.Equal("hello", strings.TrimSpace(out))
+
+	t.Run("background child", func(t *testing.T) {
+		sh, err := exec.LookPath("sh")
+		if err != nil {
+			t.Skip("sh not found")
+		}
+		assert := require.New(t)
+
+		// the background sleep keeps the output open after the command exits
+		hook := &dedicatedstorage.CommandHook{Path: sh, Args: []string{"-c", "sleep 3 & echo started"}, WaitDelay: 50 * time.Millisecond}
+		start := time.Now()
+		out, err := hook.Run(t.Context())
+		assert.NoError(err)
+		assert.Equal("started", strings.TrimSpace(out))
+		assert.Less(time.Since(start), 2*time.Second)
+	})
+}
+
+func TestFuncHook(t *testing.T) {
+	assert := require.New(t)
+	_, err := (&dedicatedstorage.FuncHook{HookName: "empty"}).Run(t.Context())
+	assert.ErrorContains(err, `hook "empty" has no function`)
+}
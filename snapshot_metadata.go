@@ -0,0 +1,220 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+)
+
+// Well-known snapshot metadata keys
+const (
+	MetadataKeySourceHost     = "host"
+	MetadataKeyAppVersion     = "app_version"
+	MetadataKeyRetentionClass = "retention"
+	MetadataKeyGroupID        = "group"
+	MetadataKeyPurpose        = "purpose"
+	MetadataKeyJobID          = "job"
+)
+
+// metadataMarker starts the encoded block at the end of a description.
+// The version following the marker tells how the rest of the block is encoded.
+const (
+	metadataMarker  = "dsmeta:"
+	metadataVersion = "v1"
+)
+
+var (
+	// ErrUnsupportedMetadataVersion is returned when a description carries metadata encoded by a newer version.
+	ErrUnsupportedMetadataVersion = errors.New("unsupported snapshot metadata version")
+	// ErrMetadataTooLarge is returned when the encoded metadata does not fit in a description.
+	ErrMetadataTooLarge = errors.New("snapshot metadata too large")
+)
+
+// SnapshotMetadata is key/value metadata stored in snapshot descriptions.
+type SnapshotMetadata map[string]string
+
+// Matches reports whether m has every key/value pair of selector.
+func (m SnapshotMetadata) Matches(selector map[string]string) bool {
+	for k, v := range selector {
+		if got, ok := m[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeSnapshotDescription returns a description made of the human-readable text followed by
+// the encoded metadata block. The text is truncated so that the result fits in MaxDescriptionLength.
+func EncodeSnapshotDescription(text string, metadata SnapshotMetadata) (string, error) {
+	if len(metadata) == 0 {
+		return truncate(text, MaxDescriptionLength), nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+	block := metadataMarker + metadataVersion + ":" + base64.RawURLEncoding.EncodeToString(data)
+
+	rest := MaxDescriptionLength - utf8.RuneCountInString(block)
+	if rest < 0 {
+		return "", fmt.Errorf("%w: %d characters encoded", ErrMetadataTooLarge, len(block))
+	}
+	if text == "" {
+		return block, nil
+	}
+	// keep room for the newline separating the text and the block
+	if rest <= 1 {
+		return block, nil
+	}
+	return truncate(text, rest-1) + "\n" + block, nil
+}
+
+// DecodeSnapshotDescription splits a description into its human-readable text and metadata.
+// Descriptions without a metadata block are returned as is with nil metadata.
+func DecodeSnapshotDescription(description string) (string, SnapshotMetadata, error) {
+	text, block := description, description
+	if i := strings.LastIndex(description, "\n"); i >= 0 {
+		text, block = description[:i], description[i+1:]
+	} else {
+		text = ""
+	}
+	encoded, ok := strings.CutPrefix(block, metadataMarker)
+	if !ok {
+		return description, nil, nil
+	}
+
+	version, payload, ok := strings.Cut(encoded, ":")
+	if !ok {
+		return description, nil, nil
+	}
+	if version != metadataVersion {
+		return text, nil, fmt.Errorf("%w: %s", ErrUnsupportedMetadataVersion, version)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return text, nil, err
+	}
+	var metadata SnapshotMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return text, nil, err
+	}
+	return text, metadata, nil
+}
+
+// SnapshotMetadataOf returns the metadata stored in the snapshot description.
+func SnapshotMetadataOf(snapshot *v1.DiskSnapshot) (SnapshotMetadata, error) {
+	_, metadata, err := DecodeSnapshotDescription(snapshot.Description)
+	return metadata, err
+}
+
+// CreateSnapshotWithMetadata creates a snapshot whose description is request's description with metadata appended.
+func CreateSnapshotWithMetadata(ctx context.Context, api DiskAPI, diskID int64, request *v1.CreateSnapshotRequest, metadata SnapshotMetadata) (*v1.DiskSnapshot, error) {
+	const methodName = "Disk.CreateSnapshot"
+
+	if err := ValidateCreateSnapshotRequest(diskID, request); err != nil {
+		return nil, NewError(methodName, err)
+	}
+	req := *request
+	description, err := EncodeSnapshotDescription(req.DiskSnapshot.Description, metadata)
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+	req.DiskSnapshot.Description = description
+	return api.CreateSnapshot(ctx, diskID, &req)
+}
+
+// UpdateSnapshotWithMetadata updates a snapshot whose description is request's description with
+// metadata merged into the metadata the snapshot already has. Keys of metadata with an empty
+// value are removed.
+func UpdateSnapshotWithMetadata(ctx context.Context, api DiskAPI, diskID, snapshotID int64, request *v1.UpdateSnapshotRequest, metadata SnapshotMetadata) (*v1.DiskSnapshot, error) {
+	const methodName = "Disk.UpdateSnapshot"
+
+	if err := ValidateUpdateSnapshotRequest(diskID, snapshotID, request); err != nil {
+		return nil, NewError(methodName, err)
+	}
+	snapshots, err := api.ListSnapshots(ctx, diskID)
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+	idx := slices.IndexFunc(snapshots.DiskSnapshots, func(s v1.DiskSnapshot) bool { return s.ID == snapshotID })
+	if idx < 0 {
+		return nil, NewError(methodName, fmt.Errorf("snapshot %d of disk %d not found", snapshotID, diskID))
+	}
+	// metadata which cannot be decoded is not overwritten
+	current, err := SnapshotMetadataOf(&snapshots.DiskSnapshots[idx])
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+
+	merged := SnapshotMetadata{}
+	maps.Copy(merged, current)
+	for k, v := range metadata {
+		if v == "" {
+			delete(merged, k)
+		} else {
+			merged[k] = v
+		}
+	}
+
+	req := *request
+	description, err := EncodeSnapshotDescription(req.DiskSnapshot.Description, merged)
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+	req.DiskSnapshot.Description = description
+	return api.UpdateSnapshot(ctx, diskID, snapshotID, &req)
+}
+
+// FilterSnapshotsByMetadata returns the snapshots whose metadata matches selector.
+// Snapshots whose metadata cannot be decoded never match.
+func FilterSnapshotsByMetadata(snapshots []v1.DiskSnapshot, selector map[string]string) []v1.DiskSnapshot {
+	results := []v1.DiskSnapshot{}
+	for i := range snapshots {
+		metadata, err := SnapshotMetadataOf(&snapshots[i])
+		if err != nil {
+			continue
+		}
+		if metadata.Matches(selector) {
+			results = append(results, snapshots[i])
+		}
+	}
+	return results
+}
+
+// ListSnapshotsByMetadata lists the snapshots of the disk whose metadata matches selector.
+func ListSnapshotsByMetadata(ctx context.Context, api DiskAPI, diskID int64, selector map[string]string) ([]v1.DiskSnapshot, error) {
+	res, err := api.ListSnapshots(ctx, diskID)
+	if err != nil {
+		return nil, err
+	}
+	return FilterSnapshotsByMetadata(res.DiskSnapshots, selector), nil
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
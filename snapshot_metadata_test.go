@@ -0,0 +1,143 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotDescriptionCodec(t *testing.T) {
+	metadata := dedicatedstorage.SnapshotMetadata{
+		dedicatedstorage.MetadataKeySourceHost:     "db01",
+		dedicatedstorage.MetadataKeyRetentionClass: "daily",
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		metadata dedicatedstorage.SnapshotMetadata
+		wantText string
+	}{
+		{name: "text and metadata", text: "nightly backup", metadata: metadata, wantText: "nightly backup"},
+		{name: "multi-line text", text: "line1\nline2", metadata: metadata, wantText: "line1\nline2"},
+		{name: "metadata only", metadata: metadata},
+		{name: "text only", text: "plain", wantText: "plain"},
+		{name: "truncated text", text: strings.Repeat("あ", dedicatedstorage.MaxDescriptionLength), metadata: metadata},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			encoded, err := dedicatedstorage.EncodeSnapshotDescription(tt.text, tt.metadata)
+			assert.NoError(err)
+			assert.LessOrEqual(utf8.RuneCountInString(encoded), dedicatedstorage.MaxDescriptionLength)
+
+			text, decoded, err := dedicatedstorage.DecodeSnapshotDescription(encoded)
+			assert.NoError(err)
+			if tt.wantText != "" {
+				assert.Equal(tt.wantText, text)
+			}
+			if len(tt.metadata) == 0 {
+				assert.Nil(decoded)
+			} else {
+				assert.Equal(tt.metadata, decoded)
+			}
+		})
+	}
+}
+
+func TestDecodeSnapshotDescription_errors(t *testing.T) {
+	assert := require.New(t)
+
+	_, _, err := dedicatedstorage.DecodeSnapshotDescription("text\ndsmeta:v9:e30")
+	assert.True(errors.Is(err, dedicatedstorage.ErrUnsupportedMetadataVersion))
+
+	_, _, err = dedicatedstorage.DecodeSnapshotDescription("dsmeta:v1:!!!")
+	assert.Error(err)
+
+	_, err = dedicatedstorage.EncodeSnapshotDescription("", dedicatedstorage.SnapshotMetadata{
+		"huge": strings.Repeat("x", dedicatedstorage.MaxDescriptionLength),
+	})
+	assert.True(errors.Is(err, dedicatedstorage.ErrMetadataTooLarge))
+}
+
+func TestSnapshotMetadataHelpers(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{})
+	disk := store.AddDisk(v1.Disk{})
+	diskOp := fake.NewDiskOp(store)
+
+	for _, class := range []string{"daily", "weekly", "daily"} {
+		_, err := dedicatedstorage.CreateSnapshotWithMetadata(ctx, diskOp, disk.ID, &v1.CreateSnapshotRequest{
+			DiskSnapshot: v1.CreateSnapshotRequestDiskSnapshot{
+				DedicatedStorageContract: v1.CreateSnapshotRequestDiskSnapshotDedicatedStorageContract{ID: contract.ID},
+				Name:                     class,
+				Description:              class + " backup",
+			},
+		}, dedicatedstorage.SnapshotMetadata{dedicatedstorage.MetadataKeyRetentionClass: class})
+		assert.NoError(err)
+	}
+	store.AddSnapshot(v1.DiskSnapshot{Name: "manual", Description: "no metadata", Disk: disk})
+
+	daily, err := dedicatedstorage.ListSnapshotsByMetadata(ctx, diskOp, disk.ID, map[string]string{dedicatedstorage.MetadataKeyRetentionClass: "daily"})
+	assert.NoError(err)
+	assert.Len(daily, 2)
+
+	all, err := dedicatedstorage.ListSnapshotsByMetadata(ctx, diskOp, disk.ID, nil)
+	assert.NoError(err)
+	assert.Len(all, 4)
+
+	updated, err := dedicatedstorage.UpdateSnapshotWithMetadata(ctx, diskOp, disk.ID, daily[0].ID, &v1.UpdateSnapshotRequest{
+		DiskSnapshot: v1.UpdateSnapshotRequestDiskSnapshot{Name: "daily", Description: "kept"},
+	}, dedicatedstorage.SnapshotMetadata{dedicatedstorage.MetadataKeyRetentionClass: "monthly"})
+	assert.NoError(err)
+	metadata, err := dedicatedstorage.SnapshotMetadataOf(updated)
+	assert.NoError(err)
+	assert.Equal("monthly", metadata[dedicatedstorage.MetadataKeyRetentionClass])
+
+	// updates merge into the existing metadata, empty values remove keys
+	updated, err = dedicatedstorage.UpdateSnapshotWithMetadata(ctx, diskOp, disk.ID, updated.ID, &v1.UpdateSnapshotRequest{
+		DiskSnapshot: v1.UpdateSnapshotRequestDiskSnapshot{Name: "daily", Description: "kept"},
+	}, dedicatedstorage.SnapshotMetadata{dedicatedstorage.MetadataKeyPurpose: "audit"})
+	assert.NoError(err)
+	metadata, err = dedicatedstorage.SnapshotMetadataOf(updated)
+	assert.NoError(err)
+	assert.Equal(dedicatedstorage.SnapshotMetadata{dedicatedstorage.MetadataKeyRetentionClass: "monthly", dedicatedstorage.MetadataKeyPurpose: "audit"}, metadata)
+
+	updated, err = dedicatedstorage.UpdateSnapshotWithMetadata(ctx, diskOp, disk.ID, updated.ID, &v1.UpdateSnapshotRequest{
+		DiskSnapshot: v1.UpdateSnapshotRequestDiskSnapshot{Name: "daily", Description: "kept"},
+	}, dedicatedstorage.SnapshotMetadata{dedicatedstorage.MetadataKeyRetentionClass: ""})
+	assert.NoError(err)
+	metadata, err = dedicatedstorage.SnapshotMetadataOf(updated)
+	assert.NoError(err)
+	assert.Equal(dedicatedstorage.SnapshotMetadata{dedicatedstorage.MetadataKeyPurpose: "audit"}, metadata)
+
+	_, err = dedicatedstorage.CreateSnapshotWithMetadata(ctx, diskOp, disk.ID, nil, nil)
+	assert.ErrorContains(err, "request")
+	_, err = dedicatedstorage.UpdateSnapshotWithMetadata(ctx, diskOp, disk.ID, updated.ID, nil, nil)
+	assert.ErrorContains(err, "request")
+}
@@ -0,0 +1,155 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package types defines the enumerated values used by the dedicated storage API.
+//
+// The API may return values unknown to this package. They are kept as is when
+// decoding JSON and reported by the predicates as neither ready nor terminal.
+package types
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// SnapshotState is the state of a disk snapshot.
+type SnapshotState string
+
+const (
+	SnapshotStateCreating  SnapshotState = "creating"
+	SnapshotStateAvailable SnapshotState = "available"
+	SnapshotStateFailed    SnapshotState = "failed"
+	SnapshotStateDeleting  SnapshotState = "deleting"
+)
+
+// SnapshotStates are the known snapshot states.
+var SnapshotStates = []SnapshotState{SnapshotStateCreating, SnapshotStateAvailable, SnapshotStateFailed, SnapshotStateDeleting}
+
+func (s SnapshotState) String() string { return string(s) }
+
+// IsKnown reports whether s is one of SnapshotStates.
+func (s SnapshotState) IsKnown() bool { return slices.Contains(SnapshotStates, s) }
+
+// IsReady reports whether the snapshot can be restored.
+func (s SnapshotState) IsReady() bool { return s == SnapshotStateAvailable }
+
+// IsFailed reports whether the snapshot failed.
+func (s SnapshotState) IsFailed() bool { return s == SnapshotStateFailed }
+
+// IsTerminal reports whether the state no longer changes without an operation.
+func (s SnapshotState) IsTerminal() bool { return s.IsReady() || s.IsFailed() }
+
+func (s *SnapshotState) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, (*string)(s))
+}
+
+// Availability is the availability of a disk.
+type Availability string
+
+const (
+	AvailabilityAvailable    Availability = "available"
+	AvailabilityUploading    Availability = "uploading"
+	AvailabilityMigrating    Availability = "migrating"
+	AvailabilityTransferring Availability = "transferring"
+	AvailabilityFailed       Availability = "failed"
+	AvailabilityDiscontinued Availability = "discontinued"
+)
+
+// Availabilities are the known availabilities.
+var Availabilities = []Availability{
+	AvailabilityAvailable, AvailabilityUploading, AvailabilityMigrating,
+	AvailabilityTransferring, AvailabilityFailed, AvailabilityDiscontinued,
+}
+
+func (a Availability) String() string { return string(a) }
+
+// IsKnown reports whether a is one of Availabilities.
+func (a Availability) IsKnown() bool { return slices.Contains(Availabilities, a) }
+
+// IsReady reports whether the disk can be operated on.
+func (a Availability) IsReady() bool { return a == AvailabilityAvailable }
+
+// IsFailed reports whether the disk is unusable.
+func (a Availability) IsFailed() bool {
+	return a == AvailabilityFailed || a == AvailabilityDiscontinued
+}
+
+// IsTerminal reports whether the availability no longer changes without an operation.
+func (a Availability) IsTerminal() bool { return a.IsReady() || a.IsFailed() }
+
+func (a *Availability) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, (*string)(a))
+}
+
+// Connection is the interface a disk is connected to its server with.
+type Connection string
+
+const (
+	ConnectionVirtIO Connection = "virtio"
+	ConnectionIDE    Connection = "ide"
+)
+
+// Connections are the known connections.
+var Connections = []Connection{ConnectionVirtIO, ConnectionIDE}
+
+func (c Connection) String() string { return string(c) }
+
+// IsKnown reports whether c is one of Connections.
+func (c Connection) IsKnown() bool { return slices.Contains(Connections, c) }
+
+func (c *Connection) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, (*string)(c))
+}
+
+// EncryptionAlgorithm is the algorithm a disk is encrypted with.
+type EncryptionAlgorithm string
+
+const (
+	EncryptionAlgorithmNone      EncryptionAlgorithm = "none"
+	EncryptionAlgorithmAES256XTS EncryptionAlgorithm = "aes256_xts"
+)
+
+// EncryptionAlgorithms are the known encryption algorithms.
+var EncryptionAlgorithms = []EncryptionAlgorithm{EncryptionAlgorithmNone, EncryptionAlgorithmAES256XTS}
+
+func (e EncryptionAlgorithm) String() string { return string(e) }
+
+// IsKnown reports whether e is one of EncryptionAlgorithms.
+func (e EncryptionAlgorithm) IsKnown() bool { return slices.Contains(EncryptionAlgorithms, e) }
+
+// IsEncrypted reports whether the disk is encrypted. An empty algorithm means not encrypted.
+func (e EncryptionAlgorithm) IsEncrypted() bool { return e != "" && e != EncryptionAlgorithmNone }
+
+func (e *EncryptionAlgorithm) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, (*string)(e))
+}
+
+// ServiceClass is the service class of a contract plan, such as "cloud/dedicated-storage/...".
+// Service classes are defined by the plans and have no known values.
+type ServiceClass string
+
+func (s ServiceClass) String() string { return string(s) }
+
+func (s *ServiceClass) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, (*string)(s))
+}
+
+// unmarshalEnum accepts any string, treating null as empty
+func unmarshalEnum(data []byte, v *string) error {
+	if string(data) == "null" {
+		*v = ""
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
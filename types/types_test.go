@@ -0,0 +1,68 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotState(t *testing.T) {
+	assert := require.New(t)
+
+	assert.True(SnapshotStateAvailable.IsReady())
+	assert.True(SnapshotStateAvailable.IsTerminal())
+	assert.True(SnapshotStateFailed.IsFailed())
+	assert.True(SnapshotStateFailed.IsTerminal())
+	assert.False(SnapshotStateCreating.IsTerminal())
+
+	unknown := SnapshotState("archiving")
+	assert.False(unknown.IsKnown())
+	assert.False(unknown.IsTerminal())
+}
+
+func TestAvailability(t *testing.T) {
+	assert := require.New(t)
+
+	assert.True(AvailabilityAvailable.IsReady())
+	assert.False(AvailabilityMigrating.IsTerminal())
+	assert.True(AvailabilityDiscontinued.IsFailed())
+}
+
+func TestEnumJSON(t *testing.T) {
+	assert := require.New(t)
+
+	var v struct {
+		State        SnapshotState       `json:"state"`
+		Availability Availability        `json:"availability"`
+		Connection   Connection          `json:"connection"`
+		Algorithm    EncryptionAlgorithm `json:"algorithm"`
+		ServiceClass ServiceClass        `json:"service_class"`
+	}
+	data := `{"state":"restoring","availability":"available","connection":"nvme","algorithm":null,"service_class":"cloud/dedicated-storage/1"}`
+	assert.NoError(json.Unmarshal([]byte(data), &v))
+	assert.Equal(SnapshotState("restoring"), v.State)
+	assert.Equal(AvailabilityAvailable, v.Availability)
+	assert.False(v.Connection.IsKnown())
+	assert.False(v.Algorithm.IsEncrypted())
+
+	encoded, err := json.Marshal(v)
+	assert.NoError(err)
+	assert.JSONEq(`{"state":"restoring","availability":"available","connection":"nvme","algorithm":"","service_class":"cloud/dedicated-storage/1"}`, string(encoded))
+
+	assert.Error(json.Unmarshal([]byte(`{"state":1}`), &v))
+}
@@ -0,0 +1,195 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/packages-go/size"
+)
+
+// Limits of the request payloads accepted by the API
+const (
+	// MaxNameLength is the maximum length of names, in characters.
+	MaxNameLength = 64
+	// MaxDescriptionLength is the maximum length of descriptions, in characters.
+	MaxDescriptionLength = 512
+	// MaxTags is the maximum number of tags of a contract.
+	MaxTags = 10
+	// MaxTagLength is the maximum length of a tag, in characters.
+	MaxTagLength = 32
+	// ExpandSizeIncrementMB is the unit of expanded disk sizes.
+	ExpandSizeIncrementMB = size.GiB
+)
+
+// FieldError is a validation failure of a request field.
+type FieldError struct {
+	// Field is the path of the field in the request, such as "DedicatedStorageContract.Tags[1]"
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when a request fails client-side validation.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		messages = append(messages, fe.Error())
+	}
+	return "invalid request: " + strings.Join(messages, ", ")
+}
+
+// validator collects field errors
+type validator struct {
+	errors []*FieldError
+}
+
+func (v *validator) add(field, format string, args ...any) {
+	v.errors = append(v.errors, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) id(field string, id int64) {
+	if id <= 0 {
+		v.add(field, "must be positive")
+	}
+}
+
+func (v *validator) name(field, name string) {
+	if name == "" {
+		v.add(field, "is required")
+		return
+	}
+	if n := utf8.RuneCountInString(name); n > MaxNameLength {
+		v.add(field, "must be at most %d characters, got %d", MaxNameLength, n)
+	}
+}
+
+func (v *validator) description(field, description string) {
+	if n := utf8.RuneCountInString(description); n > MaxDescriptionLength {
+		v.add(field, "must be at most %d characters, got %d", MaxDescriptionLength, n)
+	}
+}
+
+func (v *validator) tags(field string, tags []string) {
+	if len(tags) > MaxTags {
+		v.add(field, "must have at most %d tags, got %d", MaxTags, len(tags))
+	}
+	seen := make(map[string]bool, len(tags))
+	for i, tag := range tags {
+		tagField := fmt.Sprintf("%s[%d]", field, i)
+		switch {
+		case tag == "":
+			v.add(tagField, "must not be empty")
+		case utf8.RuneCountInString(tag) > MaxTagLength:
+			v.add(tagField, "must be at most %d characters", MaxTagLength)
+		case strings.ContainsFunc(tag, invalidTagRune):
+			v.add(tagField, "must not contain spaces, control characters or commas: %q", tag)
+		case seen[tag]:
+			v.add(tagField, "is duplicated: %q", tag)
+		}
+		seen[tag] = true
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errors}
+}
+
+func invalidTagRune(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r) || r == ','
+}
+
+// ValidateCreateContractRequest validates a request of ContractAPI.Create.
+func ValidateCreateContractRequest(request *v1.CreateDedicatedStorageContractRequest) error {
+	v := &validator{}
+	contract := request.DedicatedStorageContract
+	v.id("DedicatedStorageContract.Plan.ID", contract.Plan.ID)
+	v.name("DedicatedStorageContract.Name", contract.Name)
+	v.description("DedicatedStorageContract.Description", contract.Description)
+	v.tags("DedicatedStorageContract.Tags", contract.Tags)
+	return v.err()
+}
+
+// ValidateUpdateContractRequest validates a request of ContractAPI.Update.
+func ValidateUpdateContractRequest(id int64, request *v1.UpdateDedicatedStorageContractRequest) error {
+	v := &validator{}
+	contract := request.DedicatedStorageContract
+	v.id("id", id)
+	v.name("DedicatedStorageContract.Name", contract.Name)
+	v.description("DedicatedStorageContract.Description", contract.Description)
+	v.tags("DedicatedStorageContract.Tags", contract.Tags)
+	return v.err()
+}
+
+// ValidateCreateSnapshotRequest validates a request of DiskAPI.CreateSnapshot.
+func ValidateCreateSnapshotRequest(diskID int64, request *v1.CreateSnapshotRequest) error {
+	v := &validator{}
+	v.id("diskID", diskID)
+	if request == nil {
+		v.add("request", "is required")
+		return v.err()
+	}
+	snapshot := request.DiskSnapshot
+	v.id("DiskSnapshot.DedicatedStorageContract.ID", snapshot.DedicatedStorageContract.ID)
+	v.name("DiskSnapshot.Name", snapshot.Name)
+	v.description("DiskSnapshot.Description", snapshot.Description)
+	return v.err()
+}
+
+// ValidateUpdateSnapshotRequest validates a request of DiskAPI.UpdateSnapshot.
+func ValidateUpdateSnapshotRequest(diskID, snapshotID int64, request *v1.UpdateSnapshotRequest) error {
+	v := &validator{}
+	v.id("diskID", diskID)
+	v.id("snapshotID", snapshotID)
+	if request == nil {
+		v.add("request", "is required")
+		return v.err()
+	}
+	v.name("DiskSnapshot.Name", request.DiskSnapshot.Name)
+	v.description("DiskSnapshot.Description", request.DiskSnapshot.Description)
+	return v.err()
+}
+
+// ValidateExpandDiskRequest validates a request of DiskAPI.Expand.
+// The expanded size must be a positive multiple of ExpandSizeIncrementMB.
+func ValidateExpandDiskRequest(diskID int64, request *v1.ExpandDiskRequest) error {
+	v := &validator{}
+	v.id("diskID", diskID)
+	if request == nil {
+		v.add("request", "is required")
+		return v.err()
+	}
+	switch sizeMB := request.ExpanedSizeMB; {
+	case sizeMB <= 0:
+		v.add("ExpanedSizeMB", "must be positive")
+	case sizeMB%ExpandSizeIncrementMB != 0:
+		v.add("ExpanedSizeMB", "must be a multiple of %dMB, got %dMB", ExpandSizeIncrementMB, sizeMB)
+	}
+	return v.err()
+}
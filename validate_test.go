@@ -0,0 +1,134 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/packages-go/size"
+	"github.com/stretchr/testify/require"
+)
+
+func fieldsOf(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var validationErr *dedicatedstorage.ValidationError
+	require.True(t, errors.As(err, &validationErr), err)
+	var fields []string
+	for _, fe := range validationErr.Errors {
+		fields = append(fields, fe.Field)
+	}
+	return fields
+}
+
+func TestValidateCreateContractRequest(t *testing.T) {
+	valid := func() v1.CreateDedicatedStorageContractRequest {
+		return v1.CreateDedicatedStorageContractRequest{
+			DedicatedStorageContract: v1.CreateDedicatedStorageContractRequestDedicatedStorageContract{
+				Plan: v1.CreateDedicatedStorageContractRequestDedicatedStorageContractPlan{ID: 1},
+				Name: "example",
+				Tags: []string{"tag1", "@auto-backup", "タグ"},
+			},
+		}
+	}
+
+	cases := []struct {
+		name   string
+		modify func(req *v1.CreateDedicatedStorageContractRequestDedicatedStorageContract)
+		want   []string
+	}{
+		{name: "valid", modify: func(*v1.CreateDedicatedStorageContractRequestDedicatedStorageContract) {}},
+		{
+			name: "missing plan and name",
+			modify: func(req *v1.CreateDedicatedStorageContractRequestDedicatedStorageContract) {
+				req.Plan.ID = 0
+				req.Name = ""
+			},
+			want: []string{"DedicatedStorageContract.Plan.ID", "DedicatedStorageContract.Name"},
+		},
+		{
+			name: "too long",
+			modify: func(req *v1.CreateDedicatedStorageContractRequestDedicatedStorageContract) {
+				req.Name = strings.Repeat("a", dedicatedstorage.MaxNameLength+1)
+				req.Description = strings.Repeat("あ", dedicatedstorage.MaxDescriptionLength+1)
+			},
+			want: []string{"DedicatedStorageContract.Name", "DedicatedStorageContract.Description"},
+		},
+		{
+			name: "invalid tags",
+			modify: func(req *v1.CreateDedicatedStorageContractRequestDedicatedStorageContract) {
+				req.Tags = []string{"ok", "with space", "a,b", "ok", ""}
+			},
+			want: []string{
+				"DedicatedStorageContract.Tags[1]",
+				"DedicatedStorageContract.Tags[2]",
+				"DedicatedStorageContract.Tags[3]",
+				"DedicatedStorageContract.Tags[4]",
+			},
+		},
+		{
+			name: "too many tags",
+			modify: func(req *v1.CreateDedicatedStorageContractRequestDedicatedStorageContract) {
+				req.Tags = nil
+				for i := range dedicatedstorage.MaxTags + 1 {
+					req.Tags = append(req.Tags, strings.Repeat("t", i+1))
+				}
+			},
+			want: []string{"DedicatedStorageContract.Tags"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := valid()
+			tc.modify(&req.DedicatedStorageContract)
+			require.Equal(t, tc.want, fieldsOf(t, dedicatedstorage.ValidateCreateContractRequest(&req)))
+		})
+	}
+}
+
+func TestValidateSnapshotRequests(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal([]string{"diskID", "request"}, fieldsOf(t, dedicatedstorage.ValidateCreateSnapshotRequest(0, nil)))
+	assert.Equal([]string{"DiskSnapshot.DedicatedStorageContract.ID", "DiskSnapshot.Name"},
+		fieldsOf(t, dedicatedstorage.ValidateCreateSnapshotRequest(1, &v1.CreateSnapshotRequest{})))
+	assert.Equal([]string{"snapshotID"}, fieldsOf(t, dedicatedstorage.ValidateUpdateSnapshotRequest(1, 0, &v1.UpdateSnapshotRequest{
+		DiskSnapshot: v1.UpdateSnapshotRequestDiskSnapshot{Name: "name"},
+	})))
+}
+
+func TestValidateExpandDiskRequest(t *testing.T) {
+	assert := require.New(t)
+
+	assert.NoError(dedicatedstorage.ValidateExpandDiskRequest(1, &v1.ExpandDiskRequest{ExpanedSizeMB: 40 * size.GiB}))
+	assert.Equal([]string{"ExpanedSizeMB"}, fieldsOf(t, dedicatedstorage.ValidateExpandDiskRequest(1, &v1.ExpandDiskRequest{ExpanedSizeMB: 1500})))
+	assert.Equal([]string{"ExpanedSizeMB"}, fieldsOf(t, dedicatedstorage.ValidateExpandDiskRequest(1, &v1.ExpandDiskRequest{ExpanedSizeMB: -size.GiB})))
+}
+
+func TestContractOp_CreateValidatesBeforeSending(t *testing.T) {
+	// the client points nowhere: validation must fail before any request is sent
+	client, err := v1.NewClient("http://127.0.0.1:0")
+	require.NoError(t, err)
+
+	_, err = dedicatedstorage.NewContractOp(client).Create(t.Context(), v1.CreateDedicatedStorageContractRequest{})
+	var validationErr *dedicatedstorage.ValidationError
+	require.True(t, errors.As(err, &validationErr), err)
+}
@@ -0,0 +1,218 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedicatedstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/types"
+	"github.com/sacloud/packages-go/wait"
+	"github.com/sacloud/saclient-go"
+)
+
+const (
+	DefaultSnapshotWaitInterval = 5 * time.Second
+	DefaultSnapshotWaitTimeout  = 20 * time.Minute
+)
+
+// ErrSnapshotFailed is returned when a waited snapshot ends up in the failed state.
+var ErrSnapshotFailed = errors.New("snapshot failed")
+
+// SnapshotWaiter polls DiskAPI.ListSnapshots until a snapshot completes.
+type SnapshotWaiter struct {
+	API DiskAPI
+	// Interval is the polling interval. DefaultSnapshotWaitInterval is used when zero.
+	Interval time.Duration
+	// Timeout is the maximum time to wait. DefaultSnapshotWaitTimeout is used when zero.
+	Timeout time.Duration
+}
+
+// NewSnapshotWaiter returns a SnapshotWaiter with the default interval and timeout.
+func NewSnapshotWaiter(api DiskAPI) *SnapshotWaiter {
+	return &SnapshotWaiter{API: api}
+}
+
+// WaitForReady waits until the snapshot becomes available and returns its latest state.
+// It returns an error wrapping ErrSnapshotFailed if the snapshot fails.
+//
+// Errors reading the snapshots, such as server errors, are retried until the timeout, and are
+// joined to the timeout error. Only a disk or a snapshot which is not found stops the wait early.
+func (w *SnapshotWaiter) WaitForReady(ctx context.Context, diskID, snapshotID int64) (*v1.DiskSnapshot, error) {
+	const methodName = "Disk.WaitSnapshot"
+
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultSnapshotWaitInterval
+	}
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSnapshotWaitTimeout
+	}
+
+	// the last read error, set by the polling goroutine
+	var (
+		mu      sync.Mutex
+		readErr error
+	)
+	waiter := &wait.PollingWaiter{
+		ReadFunc: func() (interface{}, error) {
+			snapshots, err := w.API.ListSnapshots(ctx, diskID)
+			if err != nil {
+				if saclient.IsNotFoundError(err) {
+					return nil, err
+				}
+				// a nil state keeps polling
+				mu.Lock()
+				readErr = err
+				mu.Unlock()
+				return nil, nil
+			}
+			mu.Lock()
+			readErr = nil
+			mu.Unlock()
+			for i := range snapshots.DiskSnapshots {
+				if snapshots.DiskSnapshots[i].ID == snapshotID {
+					return &snapshots.DiskSnapshots[i], nil
+				}
+			}
+			return nil, fmt.Errorf("snapshot %d not found on disk %d", snapshotID, diskID)
+		},
+		StateCheckFunc: func(target interface{}) (bool, error) {
+			snapshot := target.(*v1.DiskSnapshot)
+			state := types.SnapshotState(snapshot.SnapshotState)
+			if state.IsFailed() {
+				return false, fmt.Errorf("snapshot %d: %w", snapshotID, ErrSnapshotFailed)
+			}
+			return state.IsReady(), nil
+		},
+		Interval: interval,
+		Timeout:  timeout,
+	}
+
+	state, err := waiter.WaitForState(ctx)
+	if err != nil {
+		mu.Lock()
+		defer mu.Unlock()
+		return nil, NewError(methodName, errors.Join(err, readErr))
+	}
+	return state.(*v1.DiskSnapshot), nil
+}
+
+// ErrDiskOperationNotObserved is returned when a disk does not leave the available state after
+// an asynchronous operation was accepted, so that its completion cannot be told apart from
+// the operation not having started yet.
+var ErrDiskOperationNotObserved = errors.New("disk operation not observed")
+
+// ErrDiskFailed is returned when a waited disk ends up in a failed state.
+var ErrDiskFailed = errors.New("disk failed")
+
+// DiskOperationWaiter waits for asynchronous operations on disks, RestoreFromSnapshot and Expand,
+// which return as soon as they are accepted.
+type DiskOperationWaiter struct {
+	Reader DiskStateReader
+	// Interval is the polling interval. DefaultDiskWaitInterval is used when zero.
+	Interval time.Duration
+	// StartTimeout is the maximum time to wait for the disk to leave the available state.
+	// DefaultDiskStartTimeout is used when zero.
+	StartTimeout time.Duration
+	// Timeout is the maximum time to wait for the disk to become available again.
+	// DefaultDiskWaitTimeout is used when zero.
+	Timeout time.Duration
+}
+
+// WaitForOperation waits until the disk leaves the available state, which tells that the accepted
+// operation is running, and then until it becomes available again.
+//
+// A disk may stay available for a while after the operation is accepted, so reading it available
+// right away does not mean the operation completed. When the disk never leaves the available
+// state within StartTimeout, applied decides whether the operation completed in between two
+// polls, for example by comparing the size of the disk after Expand. It returns an error wrapping
+// ErrDiskOperationNotObserved when applied is nil or returns false.
+func (w *DiskOperationWaiter) WaitForOperation(ctx context.Context, diskID int64, applied func(disk *v1.Disk) bool) (*v1.Disk, error) {
+	const methodName = "Disk.WaitOperation"
+
+	startTimeout := w.StartTimeout
+	if startTimeout <= 0 {
+		startTimeout = DefaultDiskStartTimeout
+	}
+	var last *v1.Disk
+	started, err := w.poll(ctx, diskID, startTimeout, func(disk *v1.Disk) (bool, error) {
+		last = disk
+		return !types.Availability(disk.Availability).IsReady(), nil
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		if last != nil && applied != nil && applied(last) {
+			return last, nil
+		}
+		return nil, NewError(methodName, fmt.Errorf("%w: disk %d stayed available for %s", ErrDiskOperationNotObserved, diskID, startTimeout))
+	default:
+		return nil, NewError(methodName, err)
+	}
+	if types.Availability(started.Availability).IsFailed() {
+		return nil, NewError(methodName, fmt.Errorf("disk %d: %w: %s", diskID, ErrDiskFailed, started.Availability))
+	}
+	return w.WaitForAvailable(ctx, diskID)
+}
+
+// WaitForAvailable waits until the disk is available. It returns an error wrapping ErrDiskFailed
+// if the disk fails.
+func (w *DiskOperationWaiter) WaitForAvailable(ctx context.Context, diskID int64) (*v1.Disk, error) {
+	const methodName = "Disk.WaitAvailable"
+
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = DefaultDiskWaitTimeout
+	}
+	disk, err := w.poll(ctx, diskID, timeout, func(disk *v1.Disk) (bool, error) {
+		availability := types.Availability(disk.Availability)
+		if availability.IsFailed() {
+			return false, fmt.Errorf("disk %d: %w: %s", diskID, ErrDiskFailed, availability)
+		}
+		return availability.IsReady(), nil
+	})
+	if err != nil {
+		return nil, NewError(methodName, err)
+	}
+	return disk, nil
+}
+
+func (w *DiskOperationWaiter) poll(ctx context.Context, diskID int64, timeout time.Duration, check func(disk *v1.Disk) (bool, error)) (*v1.Disk, error) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultDiskWaitInterval
+	}
+	waiter := &wait.PollingWaiter{
+		ReadFunc: func() (interface{}, error) {
+			return w.Reader.ReadDisk(ctx, diskID)
+		},
+		StateCheckFunc: func(target interface{}) (bool, error) {
+			return check(target.(*v1.Disk))
+		},
+		Interval: interval,
+		Timeout:  timeout,
+	}
+	state, err := waiter.WaitForState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return state.(*v1.Disk), nil
+}
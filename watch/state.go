@@ -0,0 +1,179 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/sacloud/dedicated-storage-api-go/internal/fileutil"
+	"github.com/sacloud/dedicated-storage-api-go/model"
+)
+
+// stateVersion is the version of the persisted state format
+const stateVersion = 1
+
+// State is the resources seen by a poll.
+type State struct {
+	Version   int                       `json:"version"`
+	PolledAt  time.Time                 `json:"polled_at"`
+	Contracts map[int64]*model.Contract `json:"contracts"`
+	Snapshots map[int64]*model.Snapshot `json:"snapshots"`
+}
+
+func newState(polledAt time.Time) *State {
+	return &State{
+		Version:   stateVersion,
+		PolledAt:  polledAt,
+		Contracts: make(map[int64]*model.Contract),
+		Snapshots: make(map[int64]*model.Snapshot),
+	}
+}
+
+// loadState returns the state of the previous poll, an empty state when there was none
+func (w *Watcher) loadState() (*State, error) {
+	if w.state != nil {
+		return w.state, nil
+	}
+	if w.StatePath == "" {
+		return newState(time.Time{}), nil
+	}
+
+	data, err := os.ReadFile(w.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return newState(time.Time{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid watch state %s: %w", w.StatePath, err)
+	}
+	if state.Version != stateVersion {
+		return nil, fmt.Errorf("unsupported watch state version %d in %s", state.Version, w.StatePath)
+	}
+	if state.Contracts == nil {
+		state.Contracts = make(map[int64]*model.Contract)
+	}
+	if state.Snapshots == nil {
+		state.Snapshots = make(map[int64]*model.Snapshot)
+	}
+	return &state, nil
+}
+
+func (w *Watcher) saveState(state *State) error {
+	if w.StatePath != "" {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := fileutil.WriteFile(w.StatePath, data, 0o600); err != nil {
+			return err
+		}
+	}
+	w.state = state
+	return nil
+}
+
+func diffStates(previous, current *State) []Event {
+	var events []Event
+	for _, id := range sortedKeys(current.Contracts) {
+		contract := current.Contracts[id]
+		old, ok := previous.Contracts[id]
+		if !ok {
+			events = append(events, Event{Type: Added, Kind: KindContract, ID: id, Contract: contract})
+			continue
+		}
+		if changes := diffFields(old, contract); len(changes) > 0 {
+			events = append(events, Event{Type: Modified, Kind: KindContract, ID: id, Contract: contract, Changes: changes})
+		}
+	}
+	for _, id := range sortedKeys(current.Snapshots) {
+		snapshot := current.Snapshots[id]
+		old, ok := previous.Snapshots[id]
+		if !ok {
+			events = append(events, Event{Type: Added, Kind: KindSnapshot, ID: id, Snapshot: snapshot})
+			continue
+		}
+		if changes := diffFields(old, snapshot); len(changes) > 0 {
+			events = append(events, Event{Type: Modified, Kind: KindSnapshot, ID: id, Snapshot: snapshot, Changes: changes})
+		}
+	}
+	// snapshots go away before their contract
+	for _, id := range sortedKeys(previous.Snapshots) {
+		if _, ok := current.Snapshots[id]; !ok {
+			events = append(events, Event{Type: Deleted, Kind: KindSnapshot, ID: id, Snapshot: previous.Snapshots[id]})
+		}
+	}
+	for _, id := range sortedKeys(previous.Contracts) {
+		if _, ok := current.Contracts[id]; !ok {
+			events = append(events, Event{Type: Deleted, Kind: KindContract, ID: id, Contract: previous.Contracts[id]})
+		}
+	}
+	return events
+}
+
+// diffFields compares the JSON representations of the models field by field.
+// Nested objects are compared per field and arrays as a whole.
+func diffFields(old, current any) []FieldChange {
+	oldFields, currentFields := flatten(old), flatten(current)
+
+	var changes []FieldChange
+	for field, v := range currentFields {
+		if o, ok := oldFields[field]; !ok || !reflect.DeepEqual(o, v) {
+			changes = append(changes, FieldChange{Field: field, Old: oldFields[field], New: v})
+		}
+	}
+	for field, o := range oldFields {
+		if _, ok := currentFields[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, Old: o})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func flatten(v any) map[string]any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	fields := make(map[string]any)
+	flattenInto(fields, "", m)
+	return fields
+}
+
+func flattenInto(fields map[string]any, prefix string, m map[string]any) {
+	for k, v := range m {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]any); ok {
+			flattenInto(fields, k, nested)
+			continue
+		}
+		fields[k] = v
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watch turns polling of contracts and snapshots into a feed of change events.
+package watch
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	dedicatedstorage "github.com/sacloud/dedicated-storage-api-go"
+	"github.com/sacloud/dedicated-storage-api-go/model"
+)
+
+const (
+	DefaultInterval   = time.Minute
+	DefaultMaxBackoff = 10 * time.Minute
+)
+
+// EventType is the kind of change of an event.
+type EventType string
+
+const (
+	Added    EventType = "added"
+	Modified EventType = "modified"
+	Deleted  EventType = "deleted"
+	// Synced is emitted for every existing resource on resync, whether it changed or not
+	Synced EventType = "synced"
+)
+
+// Kind is the kind of resource of an event.
+type Kind string
+
+const (
+	KindContract Kind = "contract"
+	KindSnapshot Kind = "snapshot"
+)
+
+// FieldChange is a changed field of a modified resource.
+type FieldChange struct {
+	// Field is the JSON path of the field in the model, such as "disk.availability"
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// Event is a change of a contract or a snapshot.
+type Event struct {
+	Type EventType `json:"type"`
+	Kind Kind      `json:"kind"`
+	ID   int64     `json:"id"`
+	// Contract is the current contract of KindContract events, the last known one when deleted
+	Contract *model.Contract `json:"contract,omitempty"`
+	// Snapshot is the current snapshot of KindSnapshot events, the last known one when deleted
+	Snapshot *model.Snapshot `json:"snapshot,omitempty"`
+	// Changes are the changed fields of Modified events
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// Changed returns the change of the field, if any.
+func (e *Event) Changed(field string) (*FieldChange, bool) {
+	for i := range e.Changes {
+		if e.Changes[i].Field == field {
+			return &e.Changes[i], true
+		}
+	}
+	return nil, false
+}
+
+// Watcher polls contracts and their snapshots and emits the differences between polls.
+type Watcher struct {
+	Contracts dedicatedstorage.ContractAPI
+	// Interval is the polling interval. DefaultInterval is used when zero.
+	Interval time.Duration
+	// ResyncInterval is the interval of Synced events for every resource. No resync when zero.
+	ResyncInterval time.Duration
+	// MaxBackoff is the longest wait after repeated polling errors. DefaultMaxBackoff is used when zero.
+	MaxBackoff time.Duration
+	// StatePath is the file the last polled state is persisted to, so that a restarted
+	// watcher only emits what changed while it was stopped. State is kept in memory when empty.
+	StatePath string
+	// OnError is called with polling errors. Polling is retried with backoff.
+	OnError func(err error)
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	state    *State
+	lastSync time.Time
+}
+
+// Poll lists the resources once and returns the events since the previous poll.
+// The first poll without persisted state emits Added events for every resource.
+// The state is saved before Poll returns, use Run to save it only once the events are handled.
+func (w *Watcher) Poll(ctx context.Context) ([]Event, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	events, current, err := w.poll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.saveState(current); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// poll returns the events since the previous poll and the state to save once they are handled
+func (w *Watcher) poll(ctx context.Context) ([]Event, *State, error) {
+	previous, err := w.loadState()
+	if err != nil {
+		return nil, nil, err
+	}
+	current, err := w.list(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := diffStates(previous, current)
+	if w.ResyncInterval > 0 {
+		if w.lastSync.IsZero() {
+			w.lastSync = current.PolledAt
+		} else if current.PolledAt.Sub(w.lastSync) >= w.ResyncInterval {
+			events = append(events, syncEvents(current)...)
+			w.lastSync = current.PolledAt
+		}
+	}
+
+	return events, current, nil
+}
+
+// Run polls until ctx is done and calls handler with each event, in order.
+// The state is saved after handler returns for every event of a poll, so that a watcher
+// stopped before then delivers the events again when it resumes. It returns the error of ctx.
+func (w *Watcher) Run(ctx context.Context, handler func(Event)) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	maxBackoff := w.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+
+	wait := time.Duration(0)
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		if err := w.runOnce(ctx, handler); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if w.OnError != nil {
+				w.OnError(err)
+			}
+			failures++
+			wait = backoff(interval, maxBackoff, failures)
+			continue
+		}
+		failures = 0
+		wait = interval
+	}
+}
+
+// runOnce polls, calls handler with the events and saves the state unless ctx is done before
+func (w *Watcher) runOnce(ctx context.Context, handler func(Event)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	events, current, err := w.poll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		handler(event)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return w.saveState(current)
+}
+
+// Watch runs the watcher in a goroutine and returns the channel the events are sent to.
+// The channel is closed when ctx is done.
+func (w *Watcher) Watch(ctx context.Context) <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		w.Run(ctx, func(event Event) { //nolint:errcheck
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return ch
+}
+
+func (w *Watcher) list(ctx context.Context) (*State, error) {
+	now := time.Now
+	if w.Now != nil {
+		now = w.Now
+	}
+	state := newState(now())
+
+	contracts, err := w.Contracts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range contracts.DedicatedStorageContracts {
+		contract := model.ContractFromV1(&contracts.DedicatedStorageContracts[i])
+		state.Contracts[contract.ID] = contract
+
+		// a partial listing would report the missing snapshots as deleted
+		snapshots, err := w.Contracts.ListDiskSnapshots(ctx, contract.ID)
+		if err != nil {
+			return nil, err
+		}
+		for j := range snapshots.DiskSnapshots {
+			snapshot := model.SnapshotFromV1(&snapshots.DiskSnapshots[j])
+			state.Snapshots[snapshot.ID] = snapshot
+		}
+	}
+	return state, nil
+}
+
+func backoff(interval, maxBackoff time.Duration, failures int) time.Duration {
+	wait := interval
+	for i := 0; i < failures && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+func syncEvents(state *State) []Event {
+	var events []Event
+	for _, id := range sortedKeys(state.Contracts) {
+		events = append(events, Event{Type: Synced, Kind: KindContract, ID: id, Contract: state.Contracts[id]})
+	}
+	for _, id := range sortedKeys(state.Snapshots) {
+		events = append(events, Event{Type: Synced, Kind: KindSnapshot, ID: id, Snapshot: state.Snapshots[id]})
+	}
+	return events
+}
+
+func sortedKeys[V any](m map[int64]V) []int64 {
+	keys := make([]int64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,178 @@
+// Copyright 2022-2025 The sacloud/dedicated-storage-api-go Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/sacloud/dedicated-storage-api-go/apis/v1"
+	"github.com/sacloud/dedicated-storage-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func eventTypes(events []Event) []string {
+	var types []string
+	for _, e := range events {
+		types = append(types, string(e.Kind)+"/"+string(e.Type))
+	}
+	return types
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	assert := require.New(t)
+	ctx := t.Context()
+
+	store := fake.NewStore()
+	contract := store.AddContract(v1.DedicatedStorageContract{Name: "contract"})
+	disk := store.AddDisk(v1.Disk{})
+	snapshot := store.AddSnapshot(v1.DiskSnapshot{
+		SnapshotState:            "creating",
+		Disk:                     disk,
+		DedicatedStorageContract: v1.DiskSnapshotDedicatedStorageContract{ID: contract.ID},
+	})
+	contracts := fake.NewContractOp(store)
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	w := &Watcher{Contracts: contracts, StatePath: statePath}
+	events, err := w.Poll(ctx)
+	assert.NoError(err)
+	assert.Equal([]string{"contract/added", "snapshot/added"}, eventTypes(events))
+
+	events, err = w.Poll(ctx)
+	assert.NoError(err)
+	assert.Empty(events)
+
+	// changes while no watcher runs are reported by a new watcher reading the state
+	store.SetSnapshotState(snapshot.ID, "available")
+	_, err = contracts.Update(ctx, contract.ID, v1.UpdateDedicatedStorageContractRequest{
+		DedicatedStorageContract: v1.UpdateDedicatedStorageContractRequestDedicatedStorageContract{Name: "renamed"},
+	})
+	assert.NoError(err)
+
+	w = &Watcher{Contracts: contracts, StatePath: statePath}
+	events, err = w.Poll(ctx)
+	assert.NoError(err)
+	assert.Equal([]string{"contract/modified", "snapshot/modified"}, eventTypes(events))
+
+	change, ok := events[0].Changed("name")
+	assert.True(ok)
+	assert.Equal("contract", change.Old)
+	assert.Equal("renamed", change.New)
+	change, ok = events[1].Changed("state")
+	assert.True(ok)
+	assert.Equal("available", change.New)
+
+	assert.NoError(fake.NewDiskOp(store).DeleteSnapshot(ctx, disk.ID, snapshot.ID))
+	assert.NoError(contracts.Delete(ctx, contract.ID))
+	events, err = w.Poll(ctx)
+	assert.NoError(err)
+	assert.Equal([]string{"snapshot/deleted", "contract/deleted"}, eventTypes(events))
+	assert.Equal("renamed", events[1].Contract.Name)
+}
+
+func TestWatcher_Resync(t *testing.T) {
+	assert := require.New(t)
+
+	store := fake.NewStore()
+	store.AddContract(v1.DedicatedStorageContract{})
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	w := &Watcher{
+		Contracts:      fake.NewContractOp(store),
+		ResyncInterval: time.Hour,
+		Now:            func() time.Time { return now },
+	}
+
+	_, err := w.Poll(t.Context())
+	assert.NoError(err)
+
+	now = now.Add(time.Hour)
+	events, err := w.Poll(t.Context())
+	assert.NoError(err)
+	assert.Equal([]string{"contract/synced"}, eventTypes(events))
+}
+
+func TestWatcher_Run(t *testing.T) {
+	assert := require.New(t)
+
+	store := fake.NewStore()
+	store.AddContract(v1.DedicatedStorageContract{})
+	store.Fail("Contract.List", errors.New("unavailable"))
+
+	var errs []error
+	w := &Watcher{
+		Contracts: fake.NewContractOp(store),
+		Interval:  time.Millisecond,
+		OnError: func(err error) {
+			errs = append(errs, err)
+			store.Fail("Contract.List", nil)
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	event, ok := <-w.Watch(ctx)
+	assert.True(ok)
+	assert.Equal(Added, event.Type)
+	assert.Len(errs, 1)
+}
+
+func TestWatcher_RunRedelivers(t *testing.T) {
+	assert := require.New(t)
+
+	store := fake.NewStore()
+	store.AddContract(v1.DedicatedStorageContract{})
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	// the watcher is stopped while handling the event, so the state is not saved
+	ctx, cancel := context.WithCancel(t.Context())
+	w := &Watcher{Contracts: fake.NewContractOp(store), Interval: time.Millisecond, StatePath: path}
+	var handled []Event
+	assert.True(errors.Is(w.Run(ctx, func(event Event) {
+		handled = append(handled, event)
+		cancel()
+	}), context.Canceled))
+	assert.Len(handled, 1)
+	assert.NoFileExists(path)
+
+	// a resumed watcher delivers the event again and saves the state once it is handled
+	ctx, cancel = context.WithCancel(t.Context())
+	defer cancel()
+	resumed := &Watcher{Contracts: fake.NewContractOp(store), Interval: time.Millisecond, StatePath: path}
+	handled = nil
+	done := make(chan error)
+	go func() {
+		done <- resumed.Run(ctx, func(event Event) { handled = append(handled, event) })
+	}()
+	assert.Eventually(func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, time.Millisecond)
+	cancel()
+	<-done
+	assert.Len(handled, 1)
+	assert.Equal(Added, handled[0].Type)
+}
+
+func TestBackoff(t *testing.T) {
+	assert := require.New(t)
+	assert.Equal(2*time.Second, backoff(time.Second, time.Minute, 1))
+	assert.Equal(8*time.Second, backoff(time.Second, time.Minute, 3))
+	assert.Equal(time.Minute, backoff(time.Second, time.Minute, 100))
+}